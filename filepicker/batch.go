@@ -0,0 +1,152 @@
+package filepicker
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// DefaultConcurrency is the number of calls run in parallel by batch methods
+// whose BatchOpts do not set Concurrency.
+const DefaultConcurrency = 4
+
+// BatchOpts structure allows the user to configure how batch methods, like
+// StatMany, run their calls. A nil pointer sets default options.
+type BatchOpts struct {
+	// Concurrency is the maximum number of calls run in parallel. If it is
+	// not positive, DefaultConcurrency is used.
+	Concurrency int
+
+	// Limiter, if set, limits the rate of the calls made by the batch in place
+	// of the limiters in Client.Limits. Like them, it is consulted before each
+	// attempt of a call and slows down when the service reports too many
+	// calls. Without it, the calls are subject to Client.Limits.
+	Limiter *RateLimiter
+}
+
+// concurrency returns the number of workers needed to process n items.
+func (bo *BatchOpts) concurrency(n int) int {
+	workers := DefaultConcurrency
+	if bo != nil && bo.Concurrency > 0 {
+		workers = bo.Concurrency
+	}
+	if workers > n {
+		return n
+	}
+	return workers
+}
+
+// context returns a copy of ctx which makes the calls of the batch wait for
+// its rate limiter, if there is one.
+func (bo *BatchOpts) context(ctx context.Context) context.Context {
+	if bo == nil || bo.Limiter == nil {
+		return ctx
+	}
+	return withLimiter(ctx, bo.Limiter)
+}
+
+// runBatch calls fn for each index in [0, n) according to the batch options
+// and returns the errors in the order of indices. Once the context is done,
+// the calls that have not started yet fail with the context's error.
+func runBatch(ctx context.Context, n int, bo *BatchOpts, fn func(ctx context.Context, i int) error) []error {
+	errs := make([]error, n)
+	ctx = bo.context(ctx)
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < bo.concurrency(n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if errs[i] = ctx.Err(); errs[i] == nil {
+					errs[i] = fn(ctx, i)
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return errs
+}
+
+// StatResult holds the outcome of a single call made by StatMany.
+type StatResult struct {
+	Metadata Metadata
+	Err      error
+}
+
+// StatMany gets the metadata of many files. The results are returned in the
+// order of provided blobs.
+func (c *Client) StatMany(ctx context.Context, blobs []*Blob, opt *StatOpts, bo *BatchOpts) []StatResult {
+	results := make([]StatResult, len(blobs))
+	errs := runBatch(ctx, len(blobs), bo, func(ctx context.Context, i int) (err error) {
+		results[i].Metadata, err = c.StatContext(ctx, blobs[i], opt)
+		return
+	})
+	for i, err := range errs {
+		results[i].Err = err
+	}
+	return results
+}
+
+// RemoveMany deletes many files. The returned errors are in the order of
+// provided blobs, with nil values for removed files.
+func (c *Client) RemoveMany(ctx context.Context, blobs []*Blob, opt *RemoveOpts, bo *BatchOpts) []error {
+	return runBatch(ctx, len(blobs), bo, func(ctx context.Context, i int) error {
+		return c.RemoveContext(ctx, blobs[i], opt)
+	})
+}
+
+// BlobResult holds the outcome of a single call made by StoreMany or
+// ConvertMany.
+type BlobResult struct {
+	Blob *Blob
+	Err  error
+}
+
+// StoreItem describes a single file sent by StoreMany.
+type StoreItem struct {
+	// Name is the name of the file, see StoreReader.
+	Name string
+
+	// Reader provides the content of the file.
+	Reader io.Reader
+
+	// Opts defines how the file will be stored. It may be nil.
+	Opts *StoreOpts
+}
+
+// StoreMany sends the content of many files to filepicker.io. The results are
+// returned in the order of provided items.
+func (c *Client) StoreMany(ctx context.Context, items []StoreItem, bo *BatchOpts) []BlobResult {
+	return blobBatch(ctx, len(items), bo, func(ctx context.Context, i int) (*Blob, error) {
+		return c.StoreReaderContext(ctx, items[i].Name, items[i].Reader, items[i].Opts)
+	})
+}
+
+// ConvertMany converts many files with the same options and stores the
+// results. The results are returned in the order of provided blobs.
+func (c *Client) ConvertMany(ctx context.Context, blobs []*Blob, opt *ConvertOpts, bo *BatchOpts) []BlobResult {
+	if opt == nil {
+		panic("filepicker: convert options pointer cannot be set to nil")
+	}
+	return blobBatch(ctx, len(blobs), bo, func(ctx context.Context, i int) (*Blob, error) {
+		return c.ConvertAndStoreContext(ctx, blobs[i], opt)
+	})
+}
+
+// blobBatch works like runBatch but also collects the blobs returned by fn.
+func blobBatch(ctx context.Context, n int, bo *BatchOpts, fn func(ctx context.Context, i int) (*Blob, error)) []BlobResult {
+	results := make([]BlobResult, n)
+	errs := runBatch(ctx, n, bo, func(ctx context.Context, i int) (err error) {
+		results[i].Blob, err = fn(ctx, i)
+		return
+	})
+	for i, err := range errs {
+		results[i].Err = err
+	}
+	return results
+}
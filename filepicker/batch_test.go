@@ -0,0 +1,187 @@
+package filepicker_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+	"github.com/filepicker/filepicker-go/filepicker/filepickertest"
+)
+
+func TestBatchRoundTrip(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	names := []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"}
+	blobs := storeMany(t, client, names)
+
+	converted := client.ConvertMany(ctx, blobs, &filepicker.ConvertOpts{Width: 10}, nil)
+	for i, res := range converted {
+		if res.Err != nil || res.Blob.Handle() == blobs[i].Handle() {
+			t.Errorf("want converted copy; got %+v, %v (i:%d)", res.Blob, res.Err, i)
+		}
+	}
+
+	missing := srv.Blob("XXXXXXXXXXXXXXXXXXXX")
+	blobs = append(blobs[:2], append([]*filepicker.Blob{missing}, blobs[2:]...)...)
+	for i, err := range client.RemoveMany(ctx, blobs, nil, nil) {
+		if want := i != 2; (err == nil) != want {
+			t.Errorf("want ok == %t; got %v (i:%d)", want, err, i)
+		}
+	}
+	for i, res := range client.StatMany(ctx, blobs, nil, nil) {
+		if !errors.Is(res.Err, filepicker.ErrNotFound) {
+			t.Errorf("want errors.Is(err, ErrNotFound) == true; got %v (i:%d)", res.Err, i)
+		}
+	}
+	if n := srv.Len(); n != len(names) {
+		t.Errorf("want srv.Len() == %d; got %d", len(names), n)
+	}
+}
+
+// storeMany stores files whose content is their name and returns their blobs.
+func storeMany(t *testing.T, client *filepicker.Client, names []string) []*filepicker.Blob {
+	items := make([]filepicker.StoreItem, len(names))
+	for i, name := range names {
+		items[i] = filepicker.StoreItem{Name: name, Reader: strings.NewReader(name)}
+	}
+	stored := client.StoreMany(context.Background(), items, &filepicker.BatchOpts{Concurrency: 2})
+	blobs := make([]*filepicker.Blob, len(stored))
+	for i, res := range stored {
+		if res.Err != nil {
+			t.Fatalf("want err == nil; got %v (i:%d)", res.Err, i)
+		}
+		if res.Blob.Filename != names[i] {
+			t.Errorf("want filename == %q; got %q (i:%d)", names[i], res.Blob.Filename, i)
+		}
+		blobs[i] = res.Blob
+	}
+	return blobs
+}
+
+func TestBatchConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		if inFlight++; inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.Write([]byte(`{"size":1}`))
+	}
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	blobs := make([]*filepicker.Blob, 12)
+	for i := range blobs {
+		blobs[i] = filepicker.NewBlob(FakeHandle)
+	}
+	for i, res := range client.StatMany(context.Background(), blobs, nil, &filepicker.BatchOpts{Concurrency: 3}) {
+		if res.Err != nil {
+			t.Errorf("want err == nil; got %v (i:%d)", res.Err, i)
+		}
+	}
+	if maxInFlight > 3 {
+		t.Errorf("want at most 3 calls in flight; got %d", maxInFlight)
+	}
+}
+
+func TestBatchRate(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("success"))
+	}
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	blobs := make([]*filepicker.Blob, 5)
+	for i := range blobs {
+		blobs[i] = filepicker.NewBlob(FakeHandle)
+	}
+	start := time.Now()
+	limiter := filepicker.NewRateLimiter(50, 1)
+	client.RemoveMany(context.Background(), blobs, nil, &filepicker.BatchOpts{Limiter: limiter})
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("want elapsed >= 80ms; got %v", elapsed)
+	}
+	if calls := limiter.Stats().Calls; calls != 5 {
+		t.Errorf("want limiter calls == 5; got %d", calls)
+	}
+}
+
+func TestBatchRateSlowDown(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if calls++; calls == 1 {
+			http.Error(w, dummyErrStr, http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("success"))
+	}
+	client := filepicker.NewClient(FakeApiKey)
+	client.Limits = map[filepicker.Method]*filepicker.RateLimiter{
+		filepicker.MetRemove: filepicker.NewRateLimiter(1000, 10),
+	}
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	blobs := []*filepicker.Blob{filepicker.NewBlob(FakeHandle)}
+	limiter := filepicker.NewRateLimiter(1000, 10)
+	client.RemoveMany(context.Background(), blobs, nil, &filepicker.BatchOpts{Limiter: limiter})
+	if rate := limiter.Rate(); rate != 500 {
+		t.Errorf("want batch limiter rate == 500; got %v", rate)
+	}
+	if calls := client.Limits[filepicker.MetRemove].Stats().Calls; calls != 0 {
+		t.Errorf("want client limiter unused; got %d calls", calls)
+	}
+}
+
+func TestBatchCanceled(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		if calls++; calls == 2 {
+			cancel()
+		}
+		mu.Unlock()
+		w.Write([]byte("success"))
+	}
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	blobs := make([]*filepicker.Blob, 10)
+	for i := range blobs {
+		blobs[i] = filepicker.NewBlob(FakeHandle)
+	}
+	errs := client.RemoveMany(ctx, blobs, nil, &filepicker.BatchOpts{Concurrency: 1})
+	if errs[0] != nil {
+		t.Errorf("want err == nil; got %v", errs[0])
+	}
+	for i, err := range errs[2:] {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("want errors.Is(err, context.Canceled) == true; got %v (i:%d)", err, i+2)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("want 2 calls; got %d", calls)
+	}
+}
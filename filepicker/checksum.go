@@ -0,0 +1,160 @@
+package filepicker
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// Checksum selects the hash functions used to verify the integrity of the
+// transferred data. The hashes are computed while the data is sent or received
+// and compared with the values computed by filepicker service, which requires
+// an additional Stat call.
+type Checksum uint
+
+// Hash functions that can be combined in a Checksum. The hash is computed over
+// the raw bytes read from the reader of an upload or written to the writer or
+// file of a download and compared with the hash of the file as stored by the
+// service. Verification is skipped for downloads of byte Ranges, since a part
+// of the file cannot be compared with the hash of the whole. It is refused
+// with ErrUnverifiable when Base64Decode is set, because the service stores
+// the decoded data whose hash differs from the one of transferred bytes.
+const (
+	VerifyMD5    Checksum = 1 << iota // Compare MD5 hashes.
+	VerifySHA256                      // Compare SHA-256 hashes.
+)
+
+// ChecksumError is returned when the hash of the transferred data does not
+// match the hash computed by filepicker service.
+type ChecksumError struct {
+	// Tag identifies the hash function.
+	Tag MetaTag
+
+	// Want is the hex encoded hash reported by filepicker service.
+	Want string
+
+	// Got is the hex encoded hash of the transferred data.
+	Got string
+}
+
+// Error satisfies builtin.error interface.
+func (e ChecksumError) Error() string {
+	return fmt.Sprintf("filepicker: %s checksum mismatch (want %s; got %s)",
+		e.Tag, e.Want, e.Got)
+}
+
+// VerifyError is returned when the checksums of a successful transfer could not
+// be verified because the Stat call fetching them failed.
+type VerifyError struct {
+	Err error
+}
+
+// Error satisfies builtin.error interface.
+func (e VerifyError) Error() string {
+	return "filepicker: cannot verify checksums: " + e.Err.Error()
+}
+
+// Unwrap returns the error of the Stat call.
+func (e VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// digester computes the hashes of data written to it.
+type digester struct {
+	tags   []MetaTag
+	hashes []hash.Hash
+}
+
+// newDigester creates a digester that computes the hashes selected by cs. It
+// returns nil if no hash is selected.
+func newDigester(cs Checksum) *digester {
+	if cs == 0 {
+		return nil
+	}
+	dg := &digester{}
+	if cs&VerifyMD5 != 0 {
+		dg.tags, dg.hashes = append(dg.tags, TagMd5Hash), append(dg.hashes, md5.New())
+	}
+	if cs&VerifySHA256 != 0 {
+		dg.tags, dg.hashes = append(dg.tags, TagSha256), append(dg.hashes, sha256.New())
+	}
+	return dg
+}
+
+// Write satisfies io.Writer interface.
+func (dg *digester) Write(p []byte) (int, error) {
+	for _, h := range dg.hashes {
+		h.Write(p)
+	}
+	return len(p), nil
+}
+
+// metaTags returns the metadata tags under which the service reports the
+// hashes. A nil digester has none.
+func (dg *digester) metaTags() []MetaTag {
+	if dg == nil {
+		return nil
+	}
+	return dg.tags
+}
+
+// tee returns a reader that writes to the digester everything it reads from r.
+// A nil digester returns r unchanged.
+func (dg *digester) tee(r io.Reader) io.Reader {
+	if dg == nil {
+		return r
+	}
+	return io.TeeReader(r, dg)
+}
+
+// verify compares computed hashes with the ones stored in file metadata.
+func (dg *digester) verify(md Metadata) error {
+	for i, tag := range dg.tags {
+		got := hex.EncodeToString(dg.hashes[i].Sum(nil))
+		want, _ := md[string(tag)].(string)
+		if want != got {
+			return ChecksumError{Tag: tag, Want: want, Got: got}
+		}
+	}
+	return nil
+}
+
+// verifiable returns ErrUnverifiable if the checksums are requested for data
+// decoded from base64 by the service.
+func verifiable(cs Checksum, base64Decode bool) error {
+	if cs != 0 && base64Decode {
+		return ErrUnverifiable
+	}
+	return nil
+}
+
+// verify fetches the hashes of the stored file and compares them with the ones
+// computed by the digester. A nil digester skips the check.
+func (c *Client) verify(ctx context.Context, src *Blob, sec Security, dg *digester) error {
+	if dg == nil {
+		return nil
+	}
+	md, err := c.StatContext(ctx, src, &StatOpts{Tags: dg.tags, Security: sec})
+	if err != nil {
+		return VerifyError{Err: err}
+	}
+	return dg.verify(md)
+}
+
+// statSecurity selects the security of the Stat call which follows a transfer
+// made with sec. The policy of the transfer may not allow stat calls, so the
+// dedicated verifySec is preferred and, if it is not set, the client signs its
+// own policy when it can.
+func (c *Client) statSecurity(sec, verifySec Security) Security {
+	switch {
+	case verifySec != (Security{}):
+		return verifySec
+	case c.Signer != nil:
+		return Security{}
+	}
+	return sec
+}
@@ -0,0 +1,228 @@
+package filepicker_test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+	"github.com/filepicker/filepicker-go/filepicker/filepickertest"
+)
+
+func checksumHandler(content *string, corrupt bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/metadata"):
+			data := *content
+			if corrupt {
+				data += "X"
+			}
+			md5sum, sha256sum := md5.Sum([]byte(data)), sha256.Sum256([]byte(data))
+			w.Write([]byte(`{"md5":"` + hex.EncodeToString(md5sum[:]) +
+				`","sha256":"` + hex.EncodeToString(sha256sum[:]) + `"}`))
+		case req.Method == "POST":
+			file, _, err := req.FormFile("fileUpload")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			data, _ := ioutil.ReadAll(file)
+			*content = string(data)
+			w.Write([]byte(`{"url":"https://www.filepicker.io/api/file/2HHH3"}`))
+		default:
+			w.Write([]byte(*content))
+		}
+	}
+}
+
+func TestStoreVerify(t *testing.T) {
+	tests := []struct {
+		Verify  filepicker.Checksum
+		Corrupt bool
+		Tag     filepicker.MetaTag
+	}{
+		{filepicker.VerifyMD5, false, ""},
+		{filepicker.VerifyMD5 | filepicker.VerifySHA256, false, ""},
+		{filepicker.VerifyMD5, true, filepicker.TagMd5Hash},
+		{filepicker.VerifySHA256, true, filepicker.MetaTag("sha256")},
+	}
+
+	for i, test := range tests {
+		var content string
+		client := filepicker.NewClient(FakeApiKey)
+		mock := MockServer(t, client, checksumHandler(&content, test.Corrupt))
+
+		opt := &filepicker.StoreOpts{Verify: test.Verify}
+		blob, err := client.StoreReader("file.txt", strings.NewReader(storeFileContent), opt)
+		if blob == nil {
+			t.Errorf("want blob != nil; got nil (i:%d)", i)
+		}
+		switch cerr, ok := err.(filepicker.ChecksumError); {
+		case test.Tag == "" && err != nil:
+			t.Errorf("want err == nil; got %v (i:%d)", err, i)
+		case test.Tag != "" && (!ok || cerr.Tag != test.Tag):
+			t.Errorf("want %s checksum error; got %v (i:%d)", test.Tag, err, i)
+		}
+		mock.Close()
+	}
+}
+
+func TestDownloadVerify(t *testing.T) {
+	content := downloadFileContent
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, checksumHandler(&content, false))
+
+	opt := &filepicker.DownloadOpts{Verify: filepicker.VerifyMD5 | filepicker.VerifySHA256}
+	var buff bytes.Buffer
+	if _, err := client.DownloadTo(blob, opt, &buff); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	mock.Close()
+
+	mock = MockServer(t, client, checksumHandler(&content, true))
+	defer mock.Close()
+	if _, err := client.DownloadTo(blob, opt, &buff); err == nil {
+		t.Error("want err != nil; got nil")
+	}
+
+	dir, err := ioutil.TempDir("", "FP")
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "file.txt")
+	if _, ok := client.DownloadToFile(blob, opt, name).(filepicker.ChecksumError); !ok {
+		t.Error("want checksum error; got nil")
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("want file removed; got %v", err)
+	}
+}
+
+func TestVerifySecurity(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	srv.Secret = FakeSecret
+	security := func(calls ...filepicker.Method) filepicker.Security {
+		policy, err := filepicker.MakePolicy(&filepicker.PolicyOpts{
+			Expiry: time.Now().Add(time.Hour),
+			Call:   calls,
+		})
+		if err != nil {
+			t.Fatalf("want err == nil; got %v", err)
+		}
+		return filepicker.MakeSecurity(FakeSecret, policy)
+	}
+	tests := []struct {
+		VerifySecurity filepicker.Security
+		Signer         filepicker.Signer
+		Unauthorized   bool
+	}{
+		{filepicker.Security{}, nil, true},
+		{security(filepicker.MetStat), nil, false},
+		{filepicker.Security{}, filepicker.SecretSigner(FakeSecret), false},
+	}
+
+	for i, test := range tests {
+		client := srv.Client()
+		client.Signer = test.Signer
+		opt := &filepicker.StoreOpts{
+			Verify:         filepicker.VerifyMD5,
+			VerifySecurity: test.VerifySecurity,
+			Security:       security(filepicker.MetStore),
+		}
+		blob, err := client.StoreReader("file.txt", strings.NewReader(storeFileContent), opt)
+		if blob == nil || srv.Len() != i+1 {
+			t.Errorf("want blob stored; got %v, %d files (i:%d)", blob, srv.Len(), i)
+			continue
+		}
+		checkUnauthorizedVerify(t, err, test.Unauthorized, i)
+
+		dlopt := &filepicker.DownloadOpts{
+			Verify:         filepicker.VerifyMD5,
+			VerifySecurity: test.VerifySecurity,
+			Security:       security(filepicker.MetRead),
+		}
+		var buff bytes.Buffer
+		_, err = client.DownloadTo(blob, dlopt, &buff)
+		checkUnauthorizedVerify(t, err, test.Unauthorized, i)
+		if buff.String() != storeFileContent {
+			t.Errorf("want content == %q; got %q (i:%d)", storeFileContent, buff.String(), i)
+		}
+	}
+}
+
+func checkUnauthorizedVerify(t *testing.T, err error, want bool, i int) {
+	var verr filepicker.VerifyError
+	if unauthorized := errors.As(err, &verr) && errors.Is(err, filepicker.ErrUnauthorized); unauthorized != want {
+		t.Errorf("want unauthorized verification == %t; got %v (i:%d)", want, err, i)
+	}
+	if !want && err != nil {
+		t.Errorf("want err == nil; got %v (i:%d)", err, i)
+	}
+}
+
+func TestStoreVerifyBase64(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	client := srv.Client()
+	encoded := base64.StdEncoding.EncodeToString([]byte(storeFileContent))
+
+	opt := &filepicker.StoreOpts{Base64Decode: true, Verify: filepicker.VerifyMD5}
+	if _, err := client.StoreReader("file.txt", strings.NewReader(encoded), opt); err != filepicker.ErrUnverifiable {
+		t.Errorf("want err == ErrUnverifiable; got %v", err)
+	}
+	if srv.Len() != 0 {
+		t.Errorf("want nothing stored; got %d files", srv.Len())
+	}
+
+	opt.Verify = 0
+	blob, err := client.StoreReader("file.txt", strings.NewReader(encoded), opt)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	if file, ok := srv.File(blob.Handle()); !ok || string(file.Data) != storeFileContent {
+		t.Errorf("want content == %q; got %q", storeFileContent, file.Data)
+	}
+}
+
+func TestDownloadVerifyBase64(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	client := srv.Client()
+	blob := srv.Put("file.txt", []byte(base64.StdEncoding.EncodeToString([]byte(downloadFileContent))))
+
+	opt := &filepicker.DownloadOpts{Base64Decode: true, Verify: filepicker.VerifySHA256}
+	var buff bytes.Buffer
+	if _, err := client.DownloadTo(blob, opt, &buff); err != filepicker.ErrUnverifiable {
+		t.Errorf("want err == ErrUnverifiable; got %v", err)
+	}
+	dir, err := ioutil.TempDir("", "FP")
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "file.txt")
+	if err := client.DownloadToFile(blob, opt, name); err != filepicker.ErrUnverifiable {
+		t.Errorf("want err == ErrUnverifiable; got %v", err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("want no file created; got %v", err)
+	}
+
+	opt.Verify = 0
+	if _, err := client.DownloadTo(blob, opt, &buff); err != nil || buff.String() != downloadFileContent {
+		t.Errorf("want content == %q; got %q, %v", downloadFileContent, buff.String(), err)
+	}
+}
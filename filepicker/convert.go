@@ -1,6 +1,7 @@
 package filepicker
 
 import (
+	"context"
 	"net/url"
 	"path"
 	"strings"
@@ -93,6 +94,12 @@ func (co *ConvertOpts) toValues() url.Values {
 
 // ConvertAndStore TODO : (ppknap)
 func (c *Client) ConvertAndStore(src *Blob, opt *ConvertOpts) (*Blob, error) {
+	return c.ConvertAndStoreContext(context.Background(), src, opt)
+}
+
+// ConvertAndStoreContext works like ConvertAndStore but binds the request to
+// the provided context.
+func (c *Client) ConvertAndStoreContext(ctx context.Context, src *Blob, opt *ConvertOpts) (*Blob, error) {
 	const content = "application/x-www-form-urlencoded"
 	blobURL, err := url.Parse(src.URL)
 	if err != nil {
@@ -104,5 +111,8 @@ func (c *Client) ConvertAndStore(src *Blob, opt *ConvertOpts) (*Blob, error) {
 	}
 	values := opt.toValues()
 	values.Set("key", c.apiKey)
-	return storeRes(c.do("POST", blobURL.String(), content, strings.NewReader(values.Encode())))
+	if err := c.sign(values, blobPolicy(MetConvert, src)); err != nil {
+		return nil, err
+	}
+	return storeRes(c.do(withCall(ctx, &Call{Method: MetConvert, Handle: src.Handle(), Opts: opt}), "POST", blobURL.String(), content, strings.NewReader(values.Encode())))
 }
@@ -1,6 +1,7 @@
 package filepicker
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,10 +17,50 @@ type DownloadOpts struct {
 	// base64 before being written to the file.
 	Base64Decode bool `json:"base64decode,omitempty"`
 
+	// Ranges, if set, limits the download to the given byte ranges of the file.
+	// The content of the ranges is produced one after another in the order in
+	// which they were returned by the service.
+	Ranges []ByteRange `json:"-"`
+
+	// Resume makes DownloadToFile resumable. The data is written to a file with
+	// ".part" suffix which is renamed to its final name once the download is
+	// complete and verified. If the ".part" file already exists, the download
+	// continues from its current size. Ranges are ignored when this option is
+	// set. Resumable downloads need the permission to call Stat method; see
+	// VerifySecurity.
+	//
+	// The ETag or Last-Modified validator of the stored file is kept next to
+	// the ".part" file, with ".part.validator" suffix, and sent in If-Range
+	// header when the download is continued, so that a file which changed in
+	// the meantime is downloaded from scratch. A ".part" file without the
+	// validator is continued only if its checksum can be verified.
+	Resume bool `json:"-"`
+
+	// Verify selects the checksums of the received data which are compared
+	// with the ones computed by filepicker service. DownloadToFile removes the
+	// file that failed the verification. Verification needs the permission to
+	// call Stat method and returns VerifyError if the checksums could not be
+	// fetched. It is ignored when Ranges are set and cannot be combined with
+	// Base64Decode, which makes the call fail with ErrUnverifiable.
+	Verify Checksum `json:"-"`
+
+	// VerifySecurity, if set, is used instead of Security by the Stat calls
+	// made for Verify and Resume, which thus have to allow stat calls. If it
+	// is not set and the client has a Signer, the Stat calls are signed with
+	// their own policy.
+	VerifySecurity Security `json:"-"`
+
+	// Progress, if set, is called as the data is received from filepicker
+	// service. The total size is taken from Content-Length response header.
+	Progress ProgressFunc `json:"-"`
+
 	// Security stores Filepicker.io policy and signature members. If you enable
 	// security option in your developer portal, these values must be set in
 	// order to perform a valid request call.
 	Security
+
+	// ifRange is the validator sent in If-Range header of a range request.
+	ifRange string
 }
 
 // toValues takes all non-zero values from provided DownloadOpts entity and puts
@@ -30,38 +71,139 @@ func (do *DownloadOpts) toValues() url.Values {
 
 // DownloadTo TODO : (ppknap)
 func (c *Client) DownloadTo(src *Blob, opt *DownloadOpts, dst io.Writer) (int64, error) {
-	resp, err := c.download(src, opt)
+	return c.DownloadToContext(context.Background(), src, opt, dst)
+}
+
+// DownloadToContext works like DownloadTo but binds the request to the
+// provided context.
+func (c *Client) DownloadToContext(ctx context.Context, src *Blob, opt *DownloadOpts, dst io.Writer) (int64, error) {
+	dg, err := digesterOf(opt)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.download(ctx, src, opt)
 	if err != nil {
 		return 0, err
 	}
 	defer resp.Body.Close()
-	return io.Copy(dst, resp.Body)
+	n, err := io.Copy(dst, dg.tee(downloadBody(resp, opt)))
+	if err != nil || dg == nil {
+		return n, err
+	}
+	return n, c.verify(ctx, src, c.statSecurity(opt.Security, opt.VerifySecurity), dg)
 }
 
 // DownloadToFile TODO : (ppknap)
 func (c *Client) DownloadToFile(src *Blob, opt *DownloadOpts, filedir string) error {
-	resp, err := c.download(src, opt)
+	return c.DownloadToFileContext(context.Background(), src, opt, filedir)
+}
+
+// DownloadToFileContext works like DownloadToFile but binds the request to the
+// provided context.
+func (c *Client) DownloadToFileContext(ctx context.Context, src *Blob, opt *DownloadOpts, filedir string) error {
+	if opt != nil && opt.Resume {
+		return c.resumeDownload(ctx, src, opt, filedir)
+	}
+	dg, err := digesterOf(opt)
+	if err != nil {
+		return err
+	}
+	resp, err := c.download(ctx, src, opt)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	name, err := filePath(src, filedir, resp.Header.Get("X-File-Name"))
+	if err != nil {
+		return err
+	}
+	if err = saveFile(name, downloadBody(resp, opt), dg); err == nil && dg != nil {
+		err = c.verify(ctx, src, c.statSecurity(opt.Security, opt.VerifySecurity), dg)
+	}
+	if _, ok := err.(ChecksumError); ok {
+		os.Remove(name)
+	}
+	return err
+}
+
+// digesterOf creates the digester of the checksums which should be verified
+// during a download. Nothing is verified when Ranges are set, while the data
+// decoded by the service cannot be verified at all.
+func digesterOf(opt *DownloadOpts) (*digester, error) {
+	if opt == nil || len(opt.Ranges) != 0 {
+		return nil, nil
+	}
+	if err := verifiable(opt.Verify, opt.Base64Decode); err != nil {
+		return nil, err
+	}
+	return newDigester(opt.Verify), nil
+}
+
+// saveFile writes everything read from r to the named file, passing the data
+// through the digester.
+func saveFile(name string, r io.Reader, dg *digester) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, dg.tee(r))
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// filePath resolves the name of the file to which the blob is downloaded. If
+// filedir points to a directory, the file is named after the stored file.
+func filePath(src *Blob, filedir, storedName string) (string, error) {
 	directory, filename := filepath.Split(filedir)
 	if filename == "" || filename == "." {
-		if filename = resp.Header.Get("X-File-Name"); filename == "" {
-			return fmt.Errorf("filepicker: invalid file name (handle %q)",
+		if filename = storedName; filename == "" {
+			return "", fmt.Errorf("%w (handle %q)", ErrInvalidFilename,
 				src.Handle())
 		}
 	}
-	file, err := os.Create(filepath.Clean(filepath.Join(directory, filename)))
+	return filepath.Clean(filepath.Join(directory, filename)), nil
+}
+
+// downloadBody returns the reader of response body that reports the progress
+// of a download to the callback set in download options.
+func downloadBody(resp *http.Response, opt *DownloadOpts) io.Reader {
+	if opt == nil {
+		return resp.Body
+	}
+	return newProgressReader(resp.Body, resp.ContentLength, opt.Progress)
+}
+
+// DownloadRange returns a reader of the given byte ranges of the stored file.
+// If no ranges are provided, the ranges set in download options are used. The
+// caller must close the returned reader.
+func (c *Client) DownloadRange(src *Blob, opt *DownloadOpts, ranges ...ByteRange) (io.ReadCloser, error) {
+	return c.DownloadRangeContext(context.Background(), src, opt, ranges...)
+}
+
+// DownloadRangeContext works like DownloadRange but binds the request to the
+// provided context.
+func (c *Client) DownloadRangeContext(ctx context.Context, src *Blob, opt *DownloadOpts, ranges ...ByteRange) (io.ReadCloser, error) {
+	if len(ranges) != 0 {
+		rangeOpt := DownloadOpts{}
+		if opt != nil {
+			rangeOpt = *opt
+		}
+		rangeOpt.Ranges = ranges
+		opt = &rangeOpt
+	}
+	resp, err := c.download(ctx, src, opt)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer file.Close()
-	_, err = io.Copy(file, resp.Body)
-	return err
+	return struct {
+		io.Reader
+		io.Closer
+	}{downloadBody(resp, opt), resp.Body}, nil
 }
 
-func (c *Client) download(src *Blob, opt *DownloadOpts) (resp *http.Response, err error) {
+func (c *Client) download(ctx context.Context, src *Blob, opt *DownloadOpts) (resp *http.Response, err error) {
 	blobURL, err := url.Parse(src.URL)
 	if err != nil {
 		return
@@ -69,11 +211,45 @@ func (c *Client) download(src *Blob, opt *DownloadOpts) (resp *http.Response, er
 	if opt != nil {
 		blobURL.RawQuery = opt.toValues().Encode()
 	}
-	if resp, err = c.do("GET", blobURL.String(), "", nil); err != nil {
+	if err = c.signURL(blobURL, blobPolicy(MetRead, src)); err != nil {
 		return
 	}
-	if err = readError(resp); err != nil {
+	req, err := c.newRequest(withCall(ctx, &Call{Method: MetRead, Handle: src.Handle(), Opts: opt}), "GET", blobURL.String(), "", nil)
+	if err != nil {
+		return
+	}
+	setRange(req, opt)
+	if resp, err = c.send(req); err != nil {
+		return
+	}
+	if err = readRanges(resp, opt); err != nil {
+		failCall(resp, err)
 		resp.Body.Close()
 	}
 	return
 }
+
+// setRange adds the headers which request the byte ranges set in download
+// options.
+func setRange(req *http.Request, opt *DownloadOpts) {
+	if opt == nil || len(opt.Ranges) == 0 {
+		return
+	}
+	req.Header.Set("Range", rangeHeader(opt.Ranges))
+	if opt.ifRange != "" {
+		req.Header.Set("If-Range", opt.ifRange)
+	}
+}
+
+// readRanges checks the status of download response and prepares its body to
+// produce the requested ranges. A full response to an If-Range request is left
+// intact since it carries the whole content of a file that has changed.
+func readRanges(resp *http.Response, opt *DownloadOpts) error {
+	if err := readError(resp); err != nil || opt == nil {
+		return err
+	}
+	if opt.ifRange != "" && resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	return rangeBody(resp, opt.Ranges)
+}
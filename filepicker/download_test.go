@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/filepicker/filepicker-go/filepicker"
@@ -195,3 +196,35 @@ func TestDownloadToFileError(t *testing.T) {
 		t.Errorf("want error message == %q; got %q", fperr, err)
 	}
 }
+
+func TestDownloadToProgress(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(downloadFileContent)))
+		w.Write([]byte(downloadFileContent))
+	}
+
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	var last filepicker.Progress
+	opt := &filepicker.DownloadOpts{
+		Progress: func(p filepicker.Progress) {
+			last = p
+		},
+	}
+	var buff bytes.Buffer
+	if _, err := client.DownloadTo(blob, opt, &buff); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if l := int64(len(downloadFileContent)); last.Bytes != l || last.Total != l {
+		t.Errorf("want last.Bytes == last.Total == %d; got %d and %d", l, last.Bytes, last.Total)
+	}
+	if last.Rate < 0 {
+		t.Errorf("want last.Rate >= 0; got %f", last.Rate)
+	}
+	if content := buff.String(); content != downloadFileContent {
+		t.Errorf("want content == %q; got %q", downloadFileContent, content)
+	}
+}
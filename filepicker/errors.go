@@ -0,0 +1,207 @@
+package filepicker
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Errors that classify failures reported by filepicker service. Errors returned
+// by Client methods can be tested against them with errors.Is function.
+var (
+	// ErrNotFound means that the requested file does not exist.
+	ErrNotFound = errors.New("filepicker: file not found")
+
+	// ErrUnauthorized means that the API key, the policy or the signature of
+	// the call was rejected.
+	ErrUnauthorized = errors.New("filepicker: unauthorized")
+
+	// ErrPolicyExpired means that the policy of the call has expired. Errors
+	// of this kind are also ErrUnauthorized errors. The service rejects
+	// expired policies with the same status code as other credentials, so
+	// they are recognized, on a best-effort basis, by "expired" in the error
+	// message.
+	ErrPolicyExpired = errors.New("filepicker: policy expired")
+
+	// ErrQuotaExceeded means that the account has run out of its quota. It is
+	// recognized by 402 status code or, on a best-effort basis, by "quota" in
+	// the error message.
+	ErrQuotaExceeded = errors.New("filepicker: quota exceeded")
+
+	// ErrRateLimited means that too many calls were made in a short time.
+	ErrRateLimited = errors.New("filepicker: rate limited")
+
+	// ErrTooLarge means that the sent file exceeds the allowed size. It is
+	// recognized by 413 status code or, on a best-effort basis, by "too large"
+	// in the error message, which is how policy size limits are reported.
+	ErrTooLarge = errors.New("filepicker: file too large")
+
+	// ErrInvalidPolicy means that policy options cannot be turned into a valid
+	// policy.
+	ErrInvalidPolicy = errors.New("filepicker: invalid policy")
+
+	// ErrInvalidFilename means that the name of a downloaded file could not be
+	// determined.
+	ErrInvalidFilename = errors.New("filepicker: invalid file name")
+
+	// ErrNoSigner means that a call needs to sign a policy but the client has
+	// no Signer configured.
+	ErrNoSigner = errors.New("filepicker: no signer configured")
+
+	// ErrNoKey means that a keyring has no active key to sign a policy with.
+	ErrNoKey = errors.New("filepicker: no active key")
+
+	// ErrUnverifiable means that checksums were requested for a transfer in
+	// which the service decodes the data, so the hashes of transferred bytes
+	// cannot be compared with the hashes of the stored file.
+	ErrUnverifiable = errors.New("filepicker: checksums of base64 decoded data cannot be verified")
+)
+
+// Fperror represents an error that can be returned from filepicker.io service.
+// Apart from the status code and the message sent by the service, it describes
+// the failed request. Fperror can be matched against ErrNotFound,
+// ErrUnauthorized, ErrPolicyExpired, ErrQuotaExceeded, ErrRateLimited and
+// ErrTooLarge with errors.Is function.
+//
+// Fperror values are comparable, but errors returned by Client methods carry
+// Details, so they should be matched with errors.Is, which compares only Code
+// and Message of Fperror targets.
+type Fperror struct {
+	Code    int
+	Message string
+
+	// Details describe the failed request. It is nil if the error was not
+	// created from a service response.
+	Details *ErrorDetails
+}
+
+// ErrorDetails describe the request which failed with Fperror.
+type ErrorDetails struct {
+	// Method and URL identify the failed request. Sensitive query parameters
+	// of the URL, like the API key, are masked.
+	Method string
+	URL    string
+
+	// Handle is the handle of the file the request operated on, if any.
+	Handle string
+
+	// Header contains the headers of the service response.
+	Header http.Header
+}
+
+// Error satisfies builtin.error interface. It prints an error string with
+// the reason of failure.
+func (e Fperror) Error() string {
+	return fmt.Sprintf("filepicker: %d - %s", e.Code, e.Message)
+}
+
+// Unwrap returns the sentinel error which classifies the failure or nil if the
+// failure does not fall into any known class. Failures which the service does
+// not tell apart by status code are classified by case-insensitive matching of
+// the message, which is best-effort.
+func (e Fperror) Unwrap() error {
+	message := strings.ToLower(e.Message)
+	switch {
+	case e.Code == http.StatusNotFound:
+		return ErrNotFound
+	case e.Code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.Code == http.StatusRequestEntityTooLarge || strings.Contains(message, "too large"):
+		return ErrTooLarge
+	case e.Code == http.StatusPaymentRequired || strings.Contains(message, "quota"):
+		return ErrQuotaExceeded
+	case !unauthorized(e.Code):
+		return nil
+	case strings.Contains(message, "expired"):
+		return ErrPolicyExpired
+	}
+	return ErrUnauthorized
+}
+
+// Is reports whether the error matches the target. Fperror targets match when
+// their Code and Message are equal to the ones of the error. Expired policy
+// errors match ErrUnauthorized as well.
+func (e Fperror) Is(target error) bool {
+	if fperr, ok := target.(Fperror); ok {
+		return fperr.Code == e.Code && fperr.Message == e.Message
+	}
+	return target == ErrUnauthorized && e.Unwrap() == ErrPolicyExpired
+}
+
+// unauthorized reports whether the status code indicates rejected credentials.
+func unauthorized(code int) bool {
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// readError returns an error if the response status does not indicate success.
+// Partial content responses to range requests are considered successful.
+func readError(resp *http.Response) error {
+	if succeeded(resp) {
+		return nil
+	}
+	bytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return newError(resp, bytes)
+}
+
+// succeeded reports whether the response status indicates a successful call.
+func succeeded(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusPartialContent
+}
+
+// newError creates an error from the status of failed response and its body.
+func newError(resp *http.Response, body []byte) error {
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return newRangeError(resp)
+	}
+	details := &ErrorDetails{Header: resp.Header}
+	if req := resp.Request; req != nil {
+		details.Method = req.Method
+		details.URL = redactURL(req.URL)
+		details.Handle = handleOf(req.URL)
+	}
+	return Fperror{
+		Code:    resp.StatusCode,
+		Message: strings.TrimSpace(string(body)),
+		Details: details,
+	}
+}
+
+// sensitiveParams lists query parameters which must not be revealed.
+var sensitiveParams = []string{"key", "policy", "signature"}
+
+// redactURL returns the string representation of the URL with the values of
+// sensitive query parameters masked.
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redacted := *u
+	query := redacted.Query()
+	for _, param := range sensitiveParams {
+		if query.Get(param) != "" {
+			query.Set(param, "REDACTED")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// handleOf extracts the file handle from the URL of a file endpoint. It returns
+// an empty string for other endpoints.
+func handleOf(u *url.URL) string {
+	dir, file := path.Split(strings.TrimSuffix(u.Path, "/"))
+	if file == "metadata" || file == "convert" {
+		dir, file = path.Split(strings.TrimSuffix(dir, "/"))
+	}
+	if path.Base(dir) != "file" {
+		return ""
+	}
+	return file
+}
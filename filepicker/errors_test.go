@@ -0,0 +1,182 @@
+package filepicker_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		Code    int
+		Message string
+		Is      []error
+		IsNot   []error
+	}{
+		{
+			Code:    http.StatusNotFound,
+			Message: "File not found",
+			Is:      []error{filepicker.ErrNotFound},
+			IsNot:   []error{filepicker.ErrUnauthorized},
+		},
+		{
+			Code:    http.StatusForbidden,
+			Message: "Invalid signature",
+			Is:      []error{filepicker.ErrUnauthorized},
+			IsNot:   []error{filepicker.ErrPolicyExpired, filepicker.ErrNotFound},
+		},
+		{
+			Code:    http.StatusForbidden,
+			Message: "Policy expired",
+			Is:      []error{filepicker.ErrPolicyExpired, filepicker.ErrUnauthorized},
+		},
+		{
+			Code:    http.StatusForbidden,
+			Message: "File is too large",
+			Is:      []error{filepicker.ErrTooLarge},
+			IsNot:   []error{filepicker.ErrUnauthorized},
+		},
+		{
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: "",
+			Is:      []error{filepicker.ErrTooLarge},
+		},
+		{
+			Code:    http.StatusPaymentRequired,
+			Message: "Quota exceeded",
+			Is:      []error{filepicker.ErrQuotaExceeded},
+		},
+		{
+			Code:    http.StatusTooManyRequests,
+			Message: "Slow down",
+			Is:      []error{filepicker.ErrRateLimited},
+		},
+		{
+			Code:    http.StatusInternalServerError,
+			Message: "Internal error",
+			IsNot: []error{
+				filepicker.ErrNotFound, filepicker.ErrUnauthorized,
+				filepicker.ErrRateLimited, filepicker.ErrTooLarge,
+			},
+		},
+	}
+
+	for i, test := range tests {
+		err := filepicker.Fperror{Code: test.Code, Message: test.Message}
+		for _, target := range test.Is {
+			if !errors.Is(err, target) {
+				t.Errorf("want errors.Is(err, %v) == true; got false (i:%d)", target, i)
+			}
+		}
+		for _, target := range test.IsNot {
+			if errors.Is(err, target) {
+				t.Errorf("want errors.Is(err, %v) == false; got true (i:%d)", target, i)
+			}
+		}
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	tests := []struct {
+		Message string
+		Want    error
+	}{
+		{"Policy expired", filepicker.ErrPolicyExpired},
+		{"File is too large", filepicker.ErrTooLarge},
+		{"Quota exceeded", filepicker.ErrQuotaExceeded},
+		{"Invalid signature", filepicker.ErrUnauthorized},
+		{"Policy does not allow this call", filepicker.ErrUnauthorized},
+		{"File is too small", filepicker.ErrUnauthorized},
+	}
+
+	for i, test := range tests {
+		err := filepicker.Fperror{Code: http.StatusForbidden, Message: test.Message}
+		if kind := err.Unwrap(); kind != test.Want {
+			t.Errorf("want err.Unwrap() == %v; got %v (i:%d)", test.Want, kind, i)
+		}
+	}
+}
+
+func TestErrorDetails(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Request-Id", "42")
+		http.Error(w, "File not found", http.StatusNotFound)
+	}
+
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	err := client.Remove(blob, &filepicker.RemoveOpts{Security: dummySecurity})
+	var fperr filepicker.Fperror
+	if !errors.As(err, &fperr) {
+		t.Fatalf("want Fperror; got %v", err)
+	}
+	if !errors.Is(err, filepicker.ErrNotFound) {
+		t.Errorf("want errors.Is(err, ErrNotFound) == true; got false")
+	}
+	if _, ok := err.(filepicker.Fperror); !ok {
+		t.Errorf("want err.(filepicker.Fperror) to succeed; got %T", err)
+	}
+	checkDetails(t, fperr.Details)
+	if want := "filepicker: 404 - File not found"; err.Error() != want {
+		t.Errorf("want error message == %q; got %q", want, err)
+	}
+}
+
+func checkDetails(t *testing.T, details *filepicker.ErrorDetails) {
+	if details == nil {
+		t.Fatal("want fperr.Details != nil; got nil")
+	}
+	if details.Method != "DELETE" {
+		t.Errorf("want details.Method == DELETE; got %q", details.Method)
+	}
+	if details.Handle != FakeHandle {
+		t.Errorf("want details.Handle == %q; got %q", FakeHandle, details.Handle)
+	}
+	if strings.Contains(details.URL, FakeApiKey) || !strings.Contains(details.URL, "/api/file/"+FakeHandle) {
+		t.Errorf("want redacted file URL; got %q", details.URL)
+	}
+	if id := details.Header.Get("X-Request-Id"); id != "42" {
+		t.Errorf("want X-Request-Id == 42; got %q", id)
+	}
+}
+
+func TestErrorCompare(t *testing.T) {
+	_, handler := ErrorHandler(dummyErrStr)
+
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	err := client.Remove(blob, nil)
+	if err == (filepicker.Fperror{Code: http.StatusNotFound, Message: dummyErrStr}) {
+		t.Error("want error with details != error without details")
+	}
+	if !errors.Is(err, filepicker.Fperror{Code: http.StatusNotFound, Message: dummyErrStr}) {
+		t.Errorf("want errors.Is(err, Fperror) == true; got false for %v", err)
+	}
+	if errors.Is(err, filepicker.Fperror{Code: http.StatusNotFound, Message: "other"}) {
+		t.Error("want errors.Is(err, Fperror) == false for other message; got true")
+	}
+}
+
+func TestErrorInvalidFilename(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(downloadFileContent))
+	}
+
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	if err := client.DownloadToFile(blob, nil, "."); !errors.Is(err, filepicker.ErrInvalidFilename) {
+		t.Errorf("want errors.Is(err, ErrInvalidFilename) == true; got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package filepicker
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// FileInfo is the typed form of the metadata of a stored file. Fields of the
+// tags that were not requested or not returned by the service are left zero.
+type FileInfo struct {
+	Size      uint64
+	Mimetype  string
+	Filename  string
+	Width     uint64
+	Height    uint64
+	Uploaded  time.Time
+	Writeable bool
+	Md5Hash   string
+	Location  Storage
+	Path      string
+	Container string
+
+	Sha224Hash string
+	Sha256Hash string
+	Sha384Hash string
+	Sha512Hash string
+
+	// Exif holds the EXIF data of an image keyed by EXIF tag names.
+	Exif map[string]interface{}
+
+	Colorspace string
+	Pages      uint64
+	Duration   time.Duration
+
+	// Extra holds the metadata of the tags which are not described by the
+	// fields above.
+	Extra Metadata
+}
+
+// MetadataError is returned when a metadata tag has a value of unexpected
+// type.
+type MetadataError struct {
+	Tag   MetaTag
+	Value interface{}
+	Want  string
+}
+
+// Error satisfies builtin.error interface.
+func (e MetadataError) Error() string {
+	return fmt.Sprintf("filepicker: metadata tag %q has value %v of type %T, want %s",
+		e.Tag, e.Value, e.Value, e.Want)
+}
+
+// infoFields maps the tags to the FileInfo fields that hold their values.
+var infoFields = map[MetaTag]func(fi *FileInfo) interface{}{
+	TagSize:      func(fi *FileInfo) interface{} { return &fi.Size },
+	TagMimetype:  func(fi *FileInfo) interface{} { return &fi.Mimetype },
+	TagFilename:  func(fi *FileInfo) interface{} { return &fi.Filename },
+	TagWidth:     func(fi *FileInfo) interface{} { return &fi.Width },
+	TagHeight:    func(fi *FileInfo) interface{} { return &fi.Height },
+	TagUploaded:  func(fi *FileInfo) interface{} { return &fi.Uploaded },
+	TagWriteable: func(fi *FileInfo) interface{} { return &fi.Writeable },
+	TagMd5Hash:   func(fi *FileInfo) interface{} { return &fi.Md5Hash },
+	TagLocation:  func(fi *FileInfo) interface{} { return &fi.Location },
+	TagPath:      func(fi *FileInfo) interface{} { return &fi.Path },
+	TagContainer: func(fi *FileInfo) interface{} { return &fi.Container },
+
+	TagSha224:     func(fi *FileInfo) interface{} { return &fi.Sha224Hash },
+	TagSha256:     func(fi *FileInfo) interface{} { return &fi.Sha256Hash },
+	TagSha384:     func(fi *FileInfo) interface{} { return &fi.Sha384Hash },
+	TagSha512:     func(fi *FileInfo) interface{} { return &fi.Sha512Hash },
+	TagExif:       func(fi *FileInfo) interface{} { return &fi.Exif },
+	TagColorspace: func(fi *FileInfo) interface{} { return &fi.Colorspace },
+	TagPages:      func(fi *FileInfo) interface{} { return &fi.Pages },
+	TagDuration:   func(fi *FileInfo) interface{} { return &fi.Duration },
+}
+
+// Info converts the metadata into FileInfo. Null values are treated as missing
+// ones. It returns MetadataError if a value has an unexpected type instead of
+// panicking like the accessor methods of Metadata.
+func (md Metadata) Info() (*FileInfo, error) {
+	fi := &FileInfo{}
+	for key, val := range md {
+		field, ok := infoFields[MetaTag(key)]
+		if !ok {
+			if fi.Extra == nil {
+				fi.Extra = make(Metadata)
+			}
+			fi.Extra[key] = val
+			continue
+		}
+		if val == nil {
+			continue
+		}
+		if err := decodeTag(field(fi), MetaTag(key), val); err != nil {
+			return nil, err
+		}
+	}
+	return fi, nil
+}
+
+// StatInfo works like Stat but returns the metadata as FileInfo.
+func (c *Client) StatInfo(src *Blob, opt *StatOpts) (*FileInfo, error) {
+	return c.StatInfoContext(context.Background(), src, opt)
+}
+
+// StatInfoContext works like StatInfo but binds the request to the provided
+// context.
+func (c *Client) StatInfoContext(ctx context.Context, src *Blob, opt *StatOpts) (*FileInfo, error) {
+	md, err := c.StatContext(ctx, src, opt)
+	if err != nil {
+		return nil, err
+	}
+	return md.Info()
+}
+
+// decodeTag stores the value of the tag in the field pointed to by dst.
+func decodeTag(dst interface{}, tag MetaTag, val interface{}) error {
+	switch dst := dst.(type) {
+	case *string:
+		return decodeString(dst, tag, val)
+	case *Storage:
+		return decodeString((*string)(dst), tag, val)
+	case *bool:
+		return decodeBool(dst, tag, val)
+	case *uint64:
+		return decodeUint(dst, tag, val)
+	case *time.Time:
+		return decodeTime(dst, tag, val)
+	case *time.Duration:
+		return decodeDuration(dst, tag, val)
+	case *map[string]interface{}:
+		return decodeObject(dst, tag, val)
+	}
+	return nil
+}
+
+func decodeString(dst *string, tag MetaTag, val interface{}) error {
+	s, ok := val.(string)
+	if !ok {
+		return MetadataError{Tag: tag, Value: val, Want: "string"}
+	}
+	*dst = s
+	return nil
+}
+
+func decodeBool(dst *bool, tag MetaTag, val interface{}) error {
+	b, ok := val.(bool)
+	if !ok {
+		return MetadataError{Tag: tag, Value: val, Want: "boolean"}
+	}
+	*dst = b
+	return nil
+}
+
+// decodeUint reads a non-negative integer. JSON numbers are decoded as float64
+// values, which cannot represent math.MaxUint64 exactly, so values from 2^64
+// on are rejected.
+func decodeUint(dst *uint64, tag MetaTag, val interface{}) error {
+	f, ok := val.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) || f >= 1<<64 {
+		return MetadataError{Tag: tag, Value: val, Want: "non-negative integer"}
+	}
+	*dst = uint64(f)
+	return nil
+}
+
+// decodeTime reads a time given in milliseconds since the UNIX epoch.
+func decodeTime(dst *time.Time, tag MetaTag, val interface{}) error {
+	f, ok := val.(float64)
+	if !ok || f != math.Trunc(f) || math.Abs(f) > math.MaxInt64/float64(time.Millisecond) {
+		return MetadataError{Tag: tag, Value: val, Want: "time in milliseconds"}
+	}
+	*dst = time.Unix(0, int64(f)*int64(time.Millisecond))
+	return nil
+}
+
+// decodeDuration reads a non-negative duration given in seconds.
+func decodeDuration(dst *time.Duration, tag MetaTag, val interface{}) error {
+	f, ok := val.(float64)
+	if ns := f * float64(time.Second); !ok || f < 0 || ns >= 1<<63 {
+		return MetadataError{Tag: tag, Value: val, Want: "duration in seconds"}
+	}
+	*dst = time.Duration(f * float64(time.Second))
+	return nil
+}
+
+func decodeObject(dst *map[string]interface{}, tag MetaTag, val interface{}) error {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return MetadataError{Tag: tag, Value: val, Want: "object"}
+	}
+	*dst = m
+	return nil
+}
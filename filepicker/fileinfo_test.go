@@ -0,0 +1,133 @@
+package filepicker_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+	"github.com/filepicker/filepicker-go/filepicker/filepickertest"
+)
+
+func TestMetadataInfo(t *testing.T) {
+	md := filepicker.Metadata{
+		"size":      1024.0,
+		"mimetype":  "image/png",
+		"filename":  "a.png",
+		"width":     640.0,
+		"height":    480.0,
+		"uploaded":  1257894000123.0,
+		"writeable": true,
+		"md5":       "900150983cd24fb0d6963f7d28e17f72",
+		"location":  "S3",
+		"path":      "docs/a.png",
+		"container": "bucket",
+		"sha256":    "abc",
+		"exif":      map[string]interface{}{"Make": "Canon"},
+		"pages":     3.0,
+		"duration":  1.5,
+		"cloud":     "dropbox",
+		"source":    nil,
+	}
+	want := &filepicker.FileInfo{
+		Size:       1024,
+		Mimetype:   "image/png",
+		Filename:   "a.png",
+		Width:      640,
+		Height:     480,
+		Uploaded:   time.Unix(1257894000, 123000000),
+		Writeable:  true,
+		Md5Hash:    "900150983cd24fb0d6963f7d28e17f72",
+		Location:   filepicker.S3,
+		Path:       "docs/a.png",
+		Container:  "bucket",
+		Sha256Hash: "abc",
+		Exif:       map[string]interface{}{"Make": "Canon"},
+		Pages:      3,
+		Duration:   1500 * time.Millisecond,
+		Extra:      filepicker.Metadata{"cloud": "dropbox", "source": nil},
+	}
+
+	fi, err := md.Info()
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	if !fi.Uploaded.Equal(want.Uploaded) {
+		t.Errorf("want uploaded == %v; got %v", want.Uploaded, fi.Uploaded)
+	}
+	fi.Uploaded = want.Uploaded
+	if !reflect.DeepEqual(fi, want) {
+		t.Errorf("want info == %+v; got %+v", want, fi)
+	}
+}
+
+func TestMetadataInfoError(t *testing.T) {
+	tests := []struct {
+		Metadata filepicker.Metadata
+		Tag      filepicker.MetaTag
+	}{
+		{filepicker.Metadata{"size": "1024"}, filepicker.TagSize},
+		{filepicker.Metadata{"size": -1.0}, filepicker.TagSize},
+		{filepicker.Metadata{"size": 18446744073709551616.0}, filepicker.TagSize},
+		{filepicker.Metadata{"width": 1.5}, filepicker.TagWidth},
+		{filepicker.Metadata{"filename": 7.0}, filepicker.TagFilename},
+		{filepicker.Metadata{"writeable": "true"}, filepicker.TagWriteable},
+		{filepicker.Metadata{"uploaded": "yesterday"}, filepicker.TagUploaded},
+		{filepicker.Metadata{"location": []interface{}{"S3"}}, filepicker.TagLocation},
+		{filepicker.Metadata{"exif": "Canon"}, filepicker.TagExif},
+		{filepicker.Metadata{"duration": -1.0}, filepicker.TagDuration},
+	}
+
+	for i, test := range tests {
+		fi, err := test.Metadata.Info()
+		var mderr filepicker.MetadataError
+		if !errors.As(err, &mderr) {
+			t.Errorf("want MetadataError; got %v (i:%d)", err, i)
+			continue
+		}
+		if mderr.Tag != test.Tag || fi != nil {
+			t.Errorf("want error of tag %q; got %q, %+v (i:%d)", test.Tag, mderr.Tag, fi, i)
+		}
+	}
+
+	if fi, err := (filepicker.Metadata{"size": nil}).Info(); err != nil || fi.Size != 0 {
+		t.Errorf("want null size skipped; got %+v, %v", fi, err)
+	}
+}
+
+func TestStatInfo(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	client := srv.Client()
+	before := time.Now().Add(-time.Second)
+	blob := srv.Put("a.txt", []byte("ABC"))
+
+	fi, err := client.StatInfo(blob, nil)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	if fi.Size != 3 || fi.Filename != "a.txt" || !fi.Writeable || fi.Md5Hash == "" {
+		t.Errorf("want info of stored file; got %+v", fi)
+	}
+	if fi.Uploaded.Before(before) || fi.Uploaded.After(time.Now()) {
+		t.Errorf("want uploaded near %v; got %v", before, fi.Uploaded)
+	}
+
+	if _, err := client.StatInfo(srv.Blob("XXXXXXXXXXXXXXXXXXXX"), nil); !errors.Is(err, filepicker.ErrNotFound) {
+		t.Errorf("want errors.Is(err, ErrNotFound) == true; got %v", err)
+	}
+}
+
+func TestMetadataUploaded(t *testing.T) {
+	for i, ms := range []float64{1257894000000, 1257894000123, 999} {
+		md := filepicker.Metadata{"uploaded": ms}
+		fi, err := md.Info()
+		if err != nil {
+			t.Fatalf("want err == nil; got %v (i:%d)", err, i)
+		}
+		if uploaded, ok := md.Uploaded(); !ok || !uploaded.Equal(fi.Uploaded) {
+			t.Errorf("want uploaded == %v; got %v (i:%d)", fi.Uploaded, uploaded, i)
+		}
+	}
+}
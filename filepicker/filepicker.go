@@ -1,14 +1,14 @@
 package filepicker
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
-	"strings"
+	"time"
 )
 
 func init() {
@@ -24,7 +24,8 @@ const UserAgentID = "filepicker-go 0.1"
 // FilepickerURL is a link to filepicker.io service.
 const FilepickerURL = "https://www.filepicker.io/"
 
-// apiURL is a url.URL type representation of FilepickerURL address.
+// apiURL is a url.URL type representation of FilepickerURL address. It is used
+// whenever a Client or a Blob does not specify its own service address.
 var apiURL *url.URL
 
 // Storage represents cloud storage services supported by filepicker.io client.
@@ -69,12 +70,27 @@ type Blob struct {
 
 // NewBlob creates a new Blob object from a given file handle.
 func NewBlob(handle string) *Blob {
-	blobURL := url.URL{
-		Scheme: apiURL.Scheme,
-		Host:   apiURL.Host,
-		Path:   path.Join("api", "file", handle),
+	return NewBlobURL(apiURL, handle)
+}
+
+// NewBlobURL creates a new Blob object from a given file handle. The blob will
+// point to the filepicker service located at serviceURL address. If serviceURL
+// is nil, the default FilepickerURL address is used.
+func NewBlobURL(serviceURL *url.URL, handle string) *Blob {
+	return &Blob{URL: endpoint(serviceURL, "api", "file", handle).String()}
+}
+
+// endpoint creates a new URL that points to the resource located under elem
+// path of the given service address.
+func endpoint(serviceURL *url.URL, elem ...string) *url.URL {
+	if serviceURL == nil {
+		serviceURL = apiURL
+	}
+	return &url.URL{
+		Scheme: serviceURL.Scheme,
+		Host:   serviceURL.Host,
+		Path:   path.Join(append([]string{serviceURL.Path}, elem...)...),
 	}
-	return &Blob{URL: blobURL.String()}
 }
 
 // Handle returns the unique identifier of the file. Its value is used by
@@ -87,23 +103,47 @@ func (b *Blob) Handle() string {
 	return path.Base(blobURL.Path)
 }
 
-// Fperror represents an error that can be returned from filepicker.io service.
-type Fperror struct {
-	Code    int
-	Message string
-}
-
-// Error satisfies builtin.error interface. It prints an error string with
-// the reason of failure.
-func (e Fperror) Error() string {
-	return fmt.Sprintf("filepicker: %d - %s", e.Code, e.Message)
-}
-
 // Client TODO : (ppknap)
 type Client struct {
 	apiKey  string
 	storage Storage
 	Client  *http.Client
+
+	// BaseURL is the address of filepicker service used by the client. It is
+	// initialized with FilepickerURL value and can be changed in order to talk
+	// to a different deployment of the service.
+	BaseURL *url.URL
+
+	// Retry defines how the client retries calls that failed due to transient
+	// errors. If this field is nil, each call is attempted only once.
+	Retry *RetryPolicy
+
+	// Signer, when set, signs the calls whose options carry no security
+	// parameters. Each call gets its own policy which allows only that call,
+	// on the accessed file, for the duration of PolicyTTL.
+	Signer Signer
+
+	// PolicyTTL is the lifetime of policies created for the Signer. If it is
+	// not positive, DefaultPolicyTTL is used.
+	PolicyTTL time.Duration
+
+	// Limits maps the methods to the rate limiters their calls are subject
+	// to. Calls of methods missing from the map are not limited. Assigning
+	// one limiter to several methods makes them share a common quota. Batch
+	// methods can replace them with BatchOpts.Limiter.
+	Limits map[Method]*RateLimiter
+
+	// Middleware intercepts the calls made by the client. The first element
+	// is the outermost one and sees each call before the others do. Retries
+	// and rate limits are applied after all middleware.
+	Middleware []Middleware
+
+	// Logger, when set, receives a record of each call made by the client.
+	Logger Logger
+
+	// Instrumentation, when set, observes the start and the end of each call
+	// made by the client.
+	Instrumentation Instrumentation
 }
 
 // NewClient TODO : (ppknap)
@@ -118,15 +158,36 @@ func NewClientStorage(apiKey string, storage Storage) *Client {
 
 // newClient TODO : (ppknap)
 func newClient(apiKey string, storage Storage) *Client {
+	baseURL := *apiURL
 	return &Client{
 		apiKey:  apiKey,
 		storage: storage,
 		Client:  &http.Client{},
+		BaseURL: &baseURL,
 	}
 }
 
-func (c *Client) do(method, urlStr, bodyType string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequest(method, urlStr, body)
+// NewBlob creates a new Blob object from a given file handle. Unlike the
+// package level NewBlob function, the returned blob points to the client's
+// service address.
+func (c *Client) NewBlob(handle string) *Blob {
+	return NewBlobURL(c.BaseURL, handle)
+}
+
+// do sends an HTTP request bound to the provided context and returns the
+// service response. Canceling the context aborts the request in flight.
+func (c *Client) do(ctx context.Context, method, urlStr, bodyType string, body io.Reader) (*http.Response, error) {
+	req, err := c.newRequest(ctx, method, urlStr, bodyType, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(req)
+}
+
+// newRequest creates an HTTP request with headers that are common for all
+// filepicker service calls.
+func (c *Client) newRequest(ctx context.Context, method, urlStr, bodyType string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, body)
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +195,7 @@ func (c *Client) do(method, urlStr, bodyType string, body io.Reader) (*http.Resp
 		req.Header.Set("Content-Type", bodyType)
 	}
 	req.Header.Set("User-Agent", UserAgentID)
-	return c.Client.Do(req)
+	return req, nil
 }
 
 // toValues takes all non-zero values from provided interface and puts them to
@@ -152,17 +213,3 @@ func toValues(val interface{}) url.Values {
 	}
 	return values
 }
-
-func readError(resp *http.Response) error {
-	if resp.StatusCode == http.StatusOK {
-		return nil
-	}
-	bytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-	return Fperror{
-		Code:    resp.StatusCode,
-		Message: strings.TrimSpace(string(bytes)),
-	}
-}
@@ -1,7 +1,9 @@
 package filepicker_test
 
 import (
+	"context"
 	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -57,3 +59,115 @@ func TestBlobHandle(t *testing.T) {
 		t.Errorf("want blob.Handle() == %q; got %q", FakeHandle, blob.Handle())
 	}
 }
+
+func TestContextCanceled(t *testing.T) {
+	var called bool
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		called = true
+	}
+
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := []func() error{
+		func() error {
+			_, err := client.StatContext(ctx, blob, nil)
+			return err
+		},
+		func() error {
+			return client.RemoveContext(ctx, blob, nil)
+		},
+		func() error {
+			_, err := client.StoreURLContext(ctx, "http://www.address.fp", nil)
+			return err
+		},
+		func() error {
+			_, err := client.PickURLContext(ctx, "http://www.address.fp", nil)
+			return err
+		},
+		func() error {
+			_, err := client.ConvertAndStoreContext(ctx, blob, &filepicker.ConvertOpts{})
+			return err
+		},
+		func() error {
+			_, err := client.DownloadToContext(ctx, blob, nil, ioutil.Discard)
+			return err
+		},
+	}
+	for i, call := range calls {
+		if err := call(); !errors.Is(err, context.Canceled) {
+			t.Errorf("want err == context.Canceled; got %v (i:%d)", err, i)
+		}
+	}
+	if called {
+		t.Error("want called == false; got true")
+	}
+}
+
+func TestNewBlobURL(t *testing.T) {
+	tests := []struct {
+		ServiceURL string
+		URL        string
+	}{
+		{
+			ServiceURL: "http://localhost:8080",
+			URL:        "http://localhost:8080/api/file/2HHH3",
+		},
+		{
+			ServiceURL: "https://staging.filepicker.io/fp/",
+			URL:        "https://staging.filepicker.io/fp/api/file/2HHH3",
+		},
+	}
+
+	for i, test := range tests {
+		serviceURL, err := url.Parse(test.ServiceURL)
+		if err != nil {
+			t.Fatalf("want err == nil; got %v (i:%d)", err, i)
+		}
+		blob := filepicker.NewBlobURL(serviceURL, FakeHandle)
+		if blob.URL != test.URL {
+			t.Errorf("want blob.URL == %q; got %q (i:%d)", test.URL, blob.URL, i)
+		}
+		if blob.Handle() != FakeHandle {
+			t.Errorf("want blob.Handle() == %q; got %q (i:%d)", FakeHandle, blob.Handle(), i)
+		}
+	}
+}
+
+func TestClientBaseURL(t *testing.T) {
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte(`{"filename":"` + name + `"}`))
+		}))
+	}
+	staging, local := newServer("staging"), newServer("local")
+	defer staging.Close()
+	defer local.Close()
+
+	clients := map[string]*filepicker.Client{
+		"staging": filepicker.NewClient(FakeApiKey),
+		"local":   filepicker.NewClient(FakeApiKey),
+	}
+	var err error
+	if clients["staging"].BaseURL, err = url.Parse(staging.URL); err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	if clients["local"].BaseURL, err = url.Parse(local.URL); err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+
+	for name, client := range clients {
+		meta, err := client.Stat(client.NewBlob(FakeHandle), nil)
+		if err != nil {
+			t.Errorf("want err == nil; got %v (name:%s)", err, name)
+		}
+		if filename, _ := meta.Filename(); filename != name {
+			t.Errorf("want filename == %q; got %q", name, filename)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package filepickertest
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+// BadJSON is the malformed body sent in place of the real response when
+// Fault.BadJSON is set.
+const BadJSON = `{"url": "https://www.filepicker.io/api/file/`
+
+// ErrDropped is returned by the fault injecting transport when it drops
+// the connection.
+var ErrDropped = errors.New("filepickertest: connection dropped")
+
+// Fault describes a failure injected into a call. Delay is applied first,
+// then the call either fails immediately (Drop, Status) or is forwarded and
+// its response is damaged (Truncate, BadJSON).
+type Fault struct {
+	// Delay postpones the call.
+	Delay time.Duration
+
+	// Drop closes the connection without sending a response.
+	Drop bool
+
+	// Status, if set, is sent in place of the real response together with
+	// Body and Header.
+	Status int
+	Body   string
+	Header http.Header
+
+	// Truncate, if positive, cuts the response body after the given number of
+	// bytes and breaks the connection.
+	Truncate int64
+
+	// BadJSON replaces the response body with malformed JSON.
+	BadJSON bool
+}
+
+// Faults is a script of failures injected into calls to filepicker service.
+// The calls are grouped by the method, as defined by policy calls, and counted
+// from one. Faults is safe for concurrent use.
+type Faults struct {
+	mu    sync.Mutex
+	rules map[filepicker.Method]map[int]Fault
+	calls map[filepicker.Method]int
+}
+
+// NewFaults creates an empty script.
+func NewFaults() *Faults {
+	return &Faults{
+		rules: make(map[filepicker.Method]map[int]Fault),
+		calls: make(map[filepicker.Method]int),
+	}
+}
+
+// On schedules the fault for the n-th call of the given method. If n is zero,
+// the fault is injected into every call that has no fault of its own.
+func (f *Faults) On(method filepicker.Method, n int, fault Fault) *Faults {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.rules[method] == nil {
+		f.rules[method] = make(map[int]Fault)
+	}
+	f.rules[method][n] = fault
+	return f
+}
+
+// Calls returns the number of calls of the given method seen so far.
+func (f *Faults) Calls(method filepicker.Method) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// next counts the call and returns the fault scheduled for it.
+func (f *Faults) next(req *http.Request) (Fault, bool) {
+	method := MethodOf(req)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[method]++
+	if fault, ok := f.rules[method][f.calls[method]]; ok {
+		return fault, true
+	}
+	fault, ok := f.rules[method][0]
+	return fault, ok
+}
+
+// methods maps the routes of API calls to the methods which a policy must
+// allow for the calls to succeed.
+var methods = map[route]filepicker.Method{
+	{"POST", "store/*"}:        filepicker.MetStore,
+	{"POST", "pick"}:           filepicker.MetPick,
+	{"GET", "file/*"}:          filepicker.MetRead,
+	{"HEAD", "file/*"}:         filepicker.MetRead,
+	{"POST", "file/*"}:         filepicker.MetWrite,
+	{"DELETE", "file/*"}:       filepicker.MetRemove,
+	{"GET", "file/*/metadata"}: filepicker.MetStat,
+	{"GET", "file/*/convert"}:  filepicker.MetConvert,
+	{"POST", "file/*/convert"}: filepicker.MetConvert,
+}
+
+// MethodOf classifies the request sent to filepicker service by the method
+// which a policy must allow for the call to succeed. It returns an empty
+// method if the request does not match any known endpoint.
+func MethodOf(req *http.Request) filepicker.Method {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for len(parts) > 0 && parts[0] != "api" {
+		parts = parts[1:]
+	}
+	if len(parts) < 2 {
+		return ""
+	}
+	rt, _ := routeOf(req.Method, parts[1:])
+	method := methods[rt]
+	if method == filepicker.MetWrite && strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		return filepicker.MetWriteurl
+	}
+	return method
+}
+
+// Transport returns an http.RoundTripper that injects scripted faults into the
+// requests sent through next. If next is nil, http.DefaultTransport is used.
+func (f *Faults) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &faultTransport{faults: f, next: next}
+}
+
+type faultTransport struct {
+	faults *Faults
+	next   http.RoundTripper
+}
+
+// RoundTrip satisfies http.RoundTripper interface.
+func (ft *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fault, ok := ft.faults.next(req)
+	if !ok {
+		return ft.next.RoundTrip(req)
+	}
+	if err := delay(req, fault.Delay); err != nil {
+		return nil, err
+	}
+	switch {
+	case fault.Drop:
+		closeBody(req)
+		return nil, ErrDropped
+	case fault.Status != 0:
+		closeBody(req)
+		return fault.response(req), nil
+	}
+	resp, err := ft.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	fault.damage(resp)
+	return resp, nil
+}
+
+// response creates the response sent in place of the real one.
+func (fault Fault) response(req *http.Request) *http.Response {
+	header := http.Header{"Content-Type": {"text/plain; charset=utf-8"}}
+	for key, values := range fault.Header {
+		header[key] = values
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(fault.Status) + " " + http.StatusText(fault.Status),
+		StatusCode:    fault.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(fault.Body)),
+		ContentLength: int64(len(fault.Body)),
+		Request:       req,
+	}
+}
+
+// damage replaces the body of the real response according to the fault.
+func (fault Fault) damage(resp *http.Response) {
+	switch {
+	case fault.BadJSON:
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(strings.NewReader(BadJSON))
+		resp.ContentLength = int64(len(BadJSON))
+		resp.Header.Del("Content-Length")
+	case fault.Truncate > 0:
+		resp.Body = &truncatedBody{
+			Reader: io.LimitReader(resp.Body, fault.Truncate),
+			Closer: resp.Body,
+		}
+	}
+}
+
+// truncatedBody returns io.ErrUnexpectedEOF once the limit of the underlying
+// reader is reached, as if the connection was broken.
+type truncatedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// Read satisfies io.Reader interface.
+func (tb *truncatedBody) Read(p []byte) (int, error) {
+	n, err := tb.Reader.Read(p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// Handler returns an http.Handler that injects scripted faults into the calls
+// served by next, which is usually a fake Server.
+func (f *Faults) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fault, ok := f.next(req)
+		if !ok {
+			next.ServeHTTP(w, req)
+			return
+		}
+		if delay(req, fault.Delay) != nil {
+			return
+		}
+		switch {
+		case fault.Drop:
+			panic(http.ErrAbortHandler)
+		case fault.Status != 0:
+			for key, values := range fault.Header {
+				w.Header()[key] = values
+			}
+			http.Error(w, fault.Body, fault.Status)
+		case fault.BadJSON:
+			next.ServeHTTP(&badJSONWriter{ResponseWriter: w}, req)
+		case fault.Truncate > 0:
+			next.ServeHTTP(&truncatingWriter{ResponseWriter: w, left: fault.Truncate}, req)
+		default:
+			next.ServeHTTP(w, req)
+		}
+	})
+}
+
+// badJSONWriter sends malformed JSON in place of the response body.
+type badJSONWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+// WriteHeader satisfies http.ResponseWriter interface.
+func (bw *badJSONWriter) WriteHeader(code int) {
+	bw.Header().Del("Content-Length")
+	bw.ResponseWriter.WriteHeader(code)
+}
+
+// Write satisfies http.ResponseWriter interface.
+func (bw *badJSONWriter) Write(p []byte) (int, error) {
+	if !bw.written {
+		bw.written = true
+		bw.Header().Del("Content-Length")
+		if _, err := io.WriteString(bw.ResponseWriter, BadJSON); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// truncatingWriter aborts the response once the given number of bytes of its
+// body was sent.
+type truncatingWriter struct {
+	http.ResponseWriter
+	left int64
+}
+
+// Write satisfies http.ResponseWriter interface.
+func (tw *truncatingWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) < tw.left {
+		tw.left -= int64(len(p))
+		return tw.ResponseWriter.Write(p)
+	}
+	tw.ResponseWriter.Write(p[:tw.left])
+	if flusher, ok := tw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// delay waits for the given duration or until the request is canceled.
+func delay(req *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// closeBody closes the body of a request which is not going to be sent.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
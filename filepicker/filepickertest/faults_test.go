@@ -0,0 +1,181 @@
+package filepickertest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+	"github.com/filepicker/filepicker-go/filepicker/filepickertest"
+)
+
+func TestFaultsTransport(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	blob := srv.Put("file.txt", []byte(Content))
+
+	faults := filepickertest.NewFaults().
+		On(filepicker.MetStore, 1, filepickertest.Fault{Status: http.StatusServiceUnavailable, Body: "busy"}).
+		On(filepicker.MetRead, 0, filepickertest.Fault{Truncate: 4}).
+		On(filepicker.MetStat, 2, filepickertest.Fault{BadJSON: true}).
+		On(filepicker.MetRemove, 1, filepickertest.Fault{Drop: true})
+	client := srv.Client()
+	client.Client.Transport = faults.Transport(nil)
+
+	_, err := client.StoreReader("file.txt", strings.NewReader(Content), nil)
+	if !errors.Is(err, filepicker.Fperror{Code: http.StatusServiceUnavailable, Message: "busy"}) {
+		t.Errorf("want 503 error; got %v", err)
+	}
+	if _, err := client.StoreReader("file.txt", strings.NewReader(Content), nil); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+
+	var buff bytes.Buffer
+	if _, err := client.DownloadTo(blob, nil, &buff); err == nil || buff.String() != Content[:4] {
+		t.Errorf("want truncated content; got %q (err: %v)", buff.String(), err)
+	}
+
+	if _, err := client.Stat(blob, nil); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if _, err := client.Stat(blob, nil); err == nil {
+		t.Error("want err != nil; got nil")
+	}
+
+	if err := client.Remove(blob, nil); !errors.Is(err, filepickertest.ErrDropped) {
+		t.Errorf("want err == ErrDropped; got %v", err)
+	}
+	if _, ok := srv.File(blob.Handle()); !ok {
+		t.Error("want file not removed; got removed")
+	}
+
+	checkCalls(t, faults, map[filepicker.Method]int{
+		filepicker.MetStore:  2,
+		filepicker.MetRead:   1,
+		filepicker.MetStat:   2,
+		filepicker.MetRemove: 1,
+	})
+}
+
+func checkCalls(t *testing.T, faults *filepickertest.Faults, want map[filepicker.Method]int) {
+	for method, calls := range want {
+		if n := faults.Calls(method); n != calls {
+			t.Errorf("want faults.Calls(%s) == %d; got %d", method, calls, n)
+		}
+	}
+}
+
+func TestFaultsTransportRetry(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+
+	faults := filepickertest.NewFaults().
+		On(filepicker.MetStore, 1, filepickertest.Fault{
+			Status: http.StatusTooManyRequests,
+			Header: http.Header{"Retry-After": {"0"}},
+		})
+	client := srv.Client()
+	client.Client.Transport = faults.Transport(nil)
+	client.Retry = &filepicker.RetryPolicy{RetryNonIdempotent: true}
+
+	if _, err := client.StoreReader("file.txt", strings.NewReader(Content), nil); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if n := srv.Len(); n != 1 {
+		t.Errorf("want srv.Len() == 1; got %d", n)
+	}
+}
+
+func TestFaultsDelay(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	blob := srv.Put("file.txt", []byte(Content))
+
+	faults := filepickertest.NewFaults().
+		On(filepicker.MetStat, 0, filepickertest.Fault{Delay: time.Minute})
+	client := srv.Client()
+	client.Client.Transport = faults.Transport(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.StatContext(ctx, blob, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want err == context.DeadlineExceeded; got %v", err)
+	}
+}
+
+func TestFaultsHandler(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+
+	faults := filepickertest.NewFaults().
+		On(filepicker.MetRead, 1, filepickertest.Fault{Drop: true}).
+		On(filepicker.MetRead, 2, filepickertest.Fault{Truncate: 4}).
+		On(filepicker.MetStat, 1, filepickertest.Fault{BadJSON: true}).
+		On(filepicker.MetStat, 2, filepickertest.Fault{Status: http.StatusBadGateway})
+	faulty := httptest.NewServer(faults.Handler(srv))
+	defer faulty.Close()
+
+	client := srv.Client()
+	client.BaseURL, _ = url.Parse(faulty.URL)
+	blob := srv.Put("file.txt", []byte(Content))
+	blob = client.NewBlob(blob.Handle())
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.DownloadTo(blob, nil, ioutil.Discard); err == nil {
+			t.Errorf("want err != nil; got nil (i:%d)", i)
+		}
+	}
+	var buff bytes.Buffer
+	if _, err := client.DownloadTo(blob, nil, &buff); err != nil || buff.String() != Content {
+		t.Errorf("want content == %q; got %q (err: %v)", Content, buff.String(), err)
+	}
+
+	if _, err := client.Stat(blob, nil); err == nil {
+		t.Error("want err != nil; got nil")
+	}
+	_, err := client.Stat(blob, nil)
+	if fperr, ok := err.(filepicker.Fperror); !ok || fperr.Code != http.StatusBadGateway {
+		t.Errorf("want 502 error; got %v", err)
+	}
+}
+
+func TestMethodOf(t *testing.T) {
+	tests := []struct {
+		Method string
+		URL    string
+		Form   bool
+		Want   filepicker.Method
+	}{
+		{"POST", "/api/store/S3", false, filepicker.MetStore},
+		{"POST", "/api/pick", false, filepicker.MetPick},
+		{"GET", "/api/file/2HHH3", false, filepicker.MetRead},
+		{"HEAD", "/api/file/2HHH3", false, filepicker.MetRead},
+		{"POST", "/api/file/2HHH3", false, filepicker.MetWrite},
+		{"POST", "/api/file/2HHH3", true, filepicker.MetWriteurl},
+		{"DELETE", "/api/file/2HHH3", false, filepicker.MetRemove},
+		{"GET", "/api/file/2HHH3/metadata", false, filepicker.MetStat},
+		{"GET", "/api/file/2HHH3/convert", false, filepicker.MetConvert},
+		{"POST", "/api/file/2HHH3/convert", false, filepicker.MetConvert},
+		{"GET", "/prefix/api/file/2HHH3", false, filepicker.MetRead},
+		{"GET", "/api/pick", false, ""},
+		{"GET", "/api/file/2HHH3/unknown", false, ""},
+		{"GET", "/file/2HHH3", false, ""},
+	}
+
+	for i, test := range tests {
+		req := httptest.NewRequest(test.Method, test.URL, nil)
+		if test.Form {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if method := filepickertest.MethodOf(req); method != test.Want {
+			t.Errorf("want method == %q; got %q (i:%d)", test.Want, method, i)
+		}
+	}
+}
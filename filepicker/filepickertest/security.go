@@ -0,0 +1,112 @@
+package filepickertest
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+// authorize checks the API key, if required, and the security parameters of
+// the call. It responds with an error and returns false if the call is not
+// permitted. The returned policy is nil when security checks are disabled.
+//
+// The size, the path and the container of stored files are not known before
+// the upload is read, so store calls must be further checked with checkStore.
+func (s *Server) authorize(w http.ResponseWriter, req *http.Request, method filepicker.Method, handle string, needKey bool) (*filepicker.PolicyOpts, bool) {
+	if needKey && req.FormValue("key") != s.APIKey {
+		http.Error(w, "Invalid API key", http.StatusForbidden)
+		return nil, false
+	}
+	if s.Secret == "" {
+		return nil, true
+	}
+	po, reason := s.checkPolicy(req)
+	if reason == "" {
+		reason = checkCall(po, req, method, handle)
+	}
+	if reason != "" {
+		http.Error(w, reason, http.StatusForbidden)
+		return nil, false
+	}
+	return po, true
+}
+
+// checkPolicy verifies the signature of the policy sent with the request and
+// decodes it. It returns the reason of rejection if the policy is not valid.
+func (s *Server) checkPolicy(req *http.Request) (*filepicker.PolicyOpts, string) {
+	security := filepicker.Security{
+		Policy:    filepicker.Policy(req.FormValue("policy")),
+		Signature: req.FormValue("signature"),
+	}
+	if security.Policy == "" || security.Signature == "" {
+		return nil, "Missing policy or signature"
+	}
+	if !security.Verify(s.Secret) {
+		return nil, "Invalid signature"
+	}
+	po, err := filepicker.ParsePolicy(security.Policy)
+	if err != nil {
+		return nil, "Invalid policy"
+	}
+	if time.Now().After(po.Expiry) {
+		return nil, "Policy expired"
+	}
+	return po, ""
+}
+
+// checkCall verifies that the policy permits the call made with the request.
+// It returns the reason of rejection if it does not.
+func checkCall(po *filepicker.PolicyOpts, req *http.Request, method filepicker.Method, handle string) string {
+	host, _, _ := net.SplitHostPort(req.RemoteAddr)
+	dataURL := req.PostFormValue("url")
+	switch {
+	case method != filepicker.MetStore && !po.Allows(method, handle, 0, "", ""):
+		return "Policy does not allow this call"
+	case !po.AllowsIP(net.ParseIP(host)):
+		return "Policy does not allow this address"
+	case dataURL != "" && !po.AllowsURL(dataURL):
+		return "Policy does not allow this URL"
+	}
+	return ""
+}
+
+// checkConvert verifies that the requested dimensions of the converted image
+// satisfy the policy. It responds with an error and returns false if they do
+// not.
+func checkConvert(w http.ResponseWriter, req *http.Request, po *filepicker.PolicyOpts) bool {
+	if po == nil {
+		return true
+	}
+	width, _ := strconv.Atoi(req.FormValue("width"))
+	height, _ := strconv.Atoi(req.FormValue("height"))
+	if !po.AllowsConversion(width, height) {
+		http.Error(w, "Conversion exceeds policy limits", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// checkStore verifies that the size and the location of stored file satisfy
+// the policy. It responds with an error and returns false if they do not.
+func checkStore(w http.ResponseWriter, po *filepicker.PolicyOpts, file *File) bool {
+	if po == nil {
+		return true
+	}
+	size := uint64(len(file.Data))
+	switch {
+	case po.MaxSize != 0 && size > po.MaxSize:
+		http.Error(w, "File is too large", http.StatusForbidden)
+	case size < po.MinSize:
+		http.Error(w, "File is too small", http.StatusForbidden)
+	case !po.AllowsMimetype(file.Mimetype):
+		http.Error(w, "File type is not allowed", http.StatusForbidden)
+	case !po.Allows(filepicker.MetStore, "", size, file.Path, file.Container):
+		http.Error(w, "Policy does not allow this call", http.StatusForbidden)
+	default:
+		return true
+	}
+	return false
+}
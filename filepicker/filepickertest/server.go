@@ -0,0 +1,514 @@
+// Package filepickertest provides an in-memory fake of filepicker.io service
+// for testing code that uses filepicker package.
+//
+// Failures can be injected into the calls with Faults, either on the client
+// side by setting Faults.Transport as the transport of client's http.Client or
+// on the server side by serving the fake through Faults.Handler.
+package filepickertest
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+// File is a file stored by the fake service.
+type File struct {
+	Data      []byte
+	Filename  string
+	Mimetype  string
+	Location  filepicker.Storage
+	Path      string
+	Container string
+	Uploaded  time.Time
+}
+
+// Server is a stateful fake of filepicker.io service. It keeps stored files in
+// memory and implements store, pick, read, write, remove, stat and convert
+// calls. The server validates the API key of calls that send it and, if Secret
+// is set, requires each call to carry a valid policy and signature.
+type Server struct {
+	*httptest.Server
+
+	// APIKey is the key that calls must present.
+	APIKey string
+
+	// Secret is the application secret used to verify policy signatures. If it
+	// is empty, security checks are disabled. It must not be changed while
+	// the server handles requests.
+	Secret string
+
+	// FetchClient is used to download the data of files stored from URLs. If
+	// it is nil, the server uses a client which connects only to loopback
+	// addresses, like the ones of httptest servers, so that the fake never
+	// reaches the network.
+	FetchClient *http.Client
+
+	mu    sync.Mutex
+	files map[string]*File
+}
+
+// NewServer starts and returns a new fake service that accepts the given API
+// key. The caller should call Close when finished, to shut it down.
+func NewServer(apiKey string) *Server {
+	s := &Server{
+		APIKey: apiKey,
+		files:  make(map[string]*File),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Client returns a new filepicker client which talks to the fake service.
+func (s *Server) Client() *filepicker.Client {
+	client := filepicker.NewClient(s.APIKey)
+	client.BaseURL = s.baseURL()
+	return client
+}
+
+// Blob returns a blob that points to the file with the given handle.
+func (s *Server) Blob(handle string) *filepicker.Blob {
+	return filepicker.NewBlobURL(s.baseURL(), handle)
+}
+
+// Put stores a new file directly in the fake service and returns its blob.
+func (s *Server) Put(filename string, data []byte) *filepicker.Blob {
+	return s.blob(s.put(&File{
+		Data:     data,
+		Filename: filename,
+		Mimetype: http.DetectContentType(data),
+		Location: filepicker.S3,
+		Uploaded: time.Now(),
+	}))
+}
+
+// File returns a copy of the stored file with the given handle.
+func (s *Server) File(handle string) (File, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if file, ok := s.files[handle]; ok {
+		return *file, true
+	}
+	return File{}, false
+}
+
+// Len returns the number of stored files.
+func (s *Server) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.files)
+}
+
+func (s *Server) baseURL() *url.URL {
+	baseURL, err := url.Parse(s.URL)
+	if err != nil {
+		panic("filepickertest: invalid server address " + s.URL)
+	}
+	return baseURL
+}
+
+// put stores the file under a new handle.
+func (s *Server) put(file *File) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if handle := newHandle(); s.files[handle] == nil {
+			s.files[handle] = file
+			return handle
+		}
+	}
+}
+
+// get returns the file with the given handle or nil if there is none.
+func (s *Server) get(handle string) *File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.files[handle]
+}
+
+// blob creates a blob that describes the file with the given handle.
+func (s *Server) blob(handle string) *filepicker.Blob {
+	blob := s.Blob(handle)
+	if file := s.get(handle); file != nil {
+		blob.Filename = file.Filename
+		blob.Mimetype = file.Mimetype
+		blob.Size = uint64(len(file.Data))
+		blob.Key = path.Join(file.Path, handle+"_"+file.Filename)
+		blob.Container = file.Location
+		blob.Writeable = true
+		blob.Path = file.Path
+	}
+	return blob
+}
+
+// newHandle generates a random file handle.
+func newHandle() string {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	handle := make([]byte, 20)
+	for i := range handle {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			panic("filepickertest: cannot generate handle: " + err.Error())
+		}
+		handle[i] = alphabet[n.Int64()]
+	}
+	return string(handle)
+}
+
+// route identifies an API call by its HTTP method and URL path, in which the
+// storage or the file handle is replaced with "*".
+type route struct {
+	method string
+	path   string
+}
+
+// routeHandler serves an API call. The arg is the storage or the file handle
+// taken from URL path.
+type routeHandler func(s *Server, w http.ResponseWriter, req *http.Request, arg string)
+
+// fileHandler serves an API call that operates on a stored file.
+type fileHandler func(s *Server, w http.ResponseWriter, req *http.Request, handle string, file *File)
+
+// routes maps the API calls implemented by the server to their handlers.
+var routes = map[route]routeHandler{
+	{"POST", "store/*"}:        storeHandler,
+	{"POST", "pick"}:           pickHandler,
+	{"GET", "file/*"}:          withFile(readHandler(filepicker.MetRead)),
+	{"HEAD", "file/*"}:         withFile(readHandler(filepicker.MetRead)),
+	{"POST", "file/*"}:         withFile((*Server).serveWrite),
+	{"DELETE", "file/*"}:       withFile((*Server).serveRemove),
+	{"GET", "file/*/metadata"}: withFile((*Server).serveStat),
+	{"GET", "file/*/convert"}:  withFile(readHandler(filepicker.MetConvert)),
+	{"POST", "file/*/convert"}: withFile((*Server).serveConvert),
+}
+
+// ServeHTTP satisfies http.Handler interface. It routes the request to the
+// handler of the called API endpoint.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "api" {
+		http.NotFound(w, req)
+		return
+	}
+	rt, arg := routeOf(req.Method, parts[1:])
+	serve, ok := routes[rt]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	serve(s, w, req, arg)
+}
+
+// routeOf returns the route of the API call with the given parts of URL path
+// that follow "/api/", and the storage or the file handle taken from them.
+func routeOf(method string, parts []string) (route, string) {
+	var arg string
+	if len(parts) > 1 {
+		arg, parts[1] = parts[1], "*"
+	}
+	return route{method, strings.Join(parts, "/")}, arg
+}
+
+// withFile returns the handler that looks up the file whose handle is taken
+// from URL path and passes it to serve.
+func withFile(serve fileHandler) routeHandler {
+	return func(s *Server, w http.ResponseWriter, req *http.Request, handle string) {
+		file := s.get(handle)
+		if file == nil {
+			http.Error(w, "File not found", http.StatusNotFound)
+			return
+		}
+		serve(s, w, req, handle, file)
+	}
+}
+
+// readHandler returns the handler that sends the content of the file in
+// response to the call of the given method.
+func readHandler(method filepicker.Method) fileHandler {
+	return func(s *Server, w http.ResponseWriter, req *http.Request, handle string, file *File) {
+		s.serveRead(w, req, method, handle, file)
+	}
+}
+
+func storeHandler(s *Server, w http.ResponseWriter, req *http.Request, storage string) {
+	s.serveStore(w, req, filepicker.Storage(storage))
+}
+
+func pickHandler(s *Server, w http.ResponseWriter, req *http.Request, _ string) {
+	s.servePick(w, req)
+}
+
+func (s *Server) serveStore(w http.ResponseWriter, req *http.Request, storage filepicker.Storage) {
+	pol, ok := s.authorize(w, req, filepicker.MetStore, "", true)
+	if !ok {
+		return
+	}
+	file, ok := s.readUpload(w, req)
+	if !ok {
+		return
+	}
+	query := req.URL.Query()
+	file.Location = storage
+	file.Path = query.Get("path")
+	file.Container = query.Get("container")
+	if !checkStore(w, pol, file) {
+		return
+	}
+	if filename := query.Get("filename"); filename != "" {
+		file.Filename = filename
+	}
+	if mimetype := query.Get("mimetype"); mimetype != "" {
+		file.Mimetype = mimetype
+	}
+	writeJSON(w, s.blob(s.put(file)))
+}
+
+func (s *Server) servePick(w http.ResponseWriter, req *http.Request) {
+	if _, ok := s.authorize(w, req, filepicker.MetPick, "", true); !ok {
+		return
+	}
+	file, ok := s.readUpload(w, req)
+	if !ok {
+		return
+	}
+	file.Location = filepicker.S3
+	writeJSON(w, s.blob(s.put(file)))
+}
+
+// serveRead sends the content of the file. Files converted on the fly are
+// served unchanged.
+func (s *Server) serveRead(w http.ResponseWriter, req *http.Request, method filepicker.Method, handle string, file *File) {
+	if _, ok := s.authorize(w, req, method, handle, false); !ok {
+		return
+	}
+	s.mu.Lock()
+	data, filename, mimetype, uploaded := file.Data, file.Filename, file.Mimetype, file.Uploaded
+	s.mu.Unlock()
+	data, err := decodeData(req, data)
+	if err != nil {
+		http.Error(w, "Cannot decode data: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("X-File-Name", filename)
+	w.Header().Set("Content-Type", mimetype)
+	if req.FormValue("dl") == "true" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+	http.ServeContent(w, req, "", uploaded, strings.NewReader(string(data)))
+}
+
+func (s *Server) serveWrite(w http.ResponseWriter, req *http.Request, handle string, file *File) {
+	method := filepicker.MetWrite
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		method = filepicker.MetWriteurl
+	}
+	if _, ok := s.authorize(w, req, method, handle, false); !ok {
+		return
+	}
+	upload, ok := s.readUpload(w, req)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	file.Data, file.Uploaded = upload.Data, upload.Uploaded
+	s.mu.Unlock()
+	writeJSON(w, s.blob(handle))
+}
+
+func (s *Server) serveRemove(w http.ResponseWriter, req *http.Request, handle string, _ *File) {
+	if _, ok := s.authorize(w, req, filepicker.MetRemove, handle, true); !ok {
+		return
+	}
+	s.mu.Lock()
+	delete(s.files, handle)
+	s.mu.Unlock()
+	w.Write([]byte("success"))
+}
+
+func (s *Server) serveStat(w http.ResponseWriter, req *http.Request, handle string, file *File) {
+	if _, ok := s.authorize(w, req, filepicker.MetStat, handle, false); !ok {
+		return
+	}
+	s.mu.Lock()
+	md := metadata(file)
+	s.mu.Unlock()
+	query := req.URL.Query()
+	requested := filepicker.Metadata{}
+	for tag, value := range md {
+		if query.Get(tag) == "true" {
+			requested[tag] = value
+		}
+	}
+	if len(requested) == 0 {
+		requested = md
+	}
+	writeJSON(w, requested)
+}
+
+// serveConvert stores a copy of the file. The fake service does not transform
+// the content, it only applies the storage options and the target format.
+func (s *Server) serveConvert(w http.ResponseWriter, req *http.Request, handle string, file *File) {
+	pol, ok := s.authorize(w, req, filepicker.MetConvert, handle, true)
+	if !ok || !checkConvert(w, req, pol) {
+		return
+	}
+	s.mu.Lock()
+	converted := *file
+	s.mu.Unlock()
+	converted.Uploaded = time.Now()
+	if location := req.FormValue("storeLocation"); location != "" {
+		converted.Location = filepicker.Storage(location)
+	}
+	if filename := req.FormValue("filename"); filename != "" {
+		converted.Filename = filename
+	}
+	if format := req.FormValue("format"); format != "" {
+		converted.Mimetype = mime.TypeByExtension("." + format)
+	}
+	converted.Path = req.FormValue("storePath")
+	converted.Container = req.FormValue("storeContainer")
+	writeJSON(w, s.blob(s.put(&converted)))
+}
+
+// readUpload reads the file sent either as a multipart form or as a URL that
+// points to the data.
+func (s *Server) readUpload(w http.ResponseWriter, req *http.Request) (*File, bool) {
+	file := &File{Uploaded: time.Now()}
+	body, err := s.openUpload(req, file)
+	if err != nil {
+		http.Error(w, "Invalid upload: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		http.Error(w, "Cannot read data: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if data, err = decodeData(req, data); err != nil {
+		http.Error(w, "Cannot decode data: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if file.Data = data; file.Mimetype == "" || file.Mimetype == "application/octet-stream" {
+		file.Mimetype = http.DetectContentType(data)
+	}
+	return file, true
+}
+
+// decodeData decodes the data from base64 if the request asks for it.
+func decodeData(req *http.Request, data []byte) ([]byte, error) {
+	if req.FormValue("base64decode") != "true" {
+		return data, nil
+	}
+	return base64.StdEncoding.DecodeString(string(data))
+}
+
+// openUpload returns the reader of uploaded data and fills the name and the
+// type of the file if they were sent.
+func (s *Server) openUpload(req *http.Request, file *File) (io.ReadCloser, error) {
+	if dataURL := req.PostFormValue("url"); dataURL != "" {
+		resp, err := s.fetchClient().Get(dataURL)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("fetching %s: %s", dataURL, resp.Status)
+		}
+		file.Filename = path.Base(resp.Request.URL.Path)
+		file.Mimetype = resp.Header.Get("Content-Type")
+		return resp.Body, nil
+	}
+	part, header, err := req.FormFile("fileUpload")
+	if err == http.ErrMissingFile && req.MultipartForm != nil {
+		// Parts without a file name are parsed as regular form values.
+		if values := req.MultipartForm.Value["fileUpload"]; len(values) != 0 {
+			return ioutil.NopCloser(strings.NewReader(values[0])), nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	file.Filename = header.Filename
+	file.Mimetype = header.Header.Get("Content-Type")
+	return part, nil
+}
+
+// fetchClient returns the client which downloads the data of stored URLs.
+func (s *Server) fetchClient() *http.Client {
+	if s.FetchClient != nil {
+		return s.FetchClient
+	}
+	return localClient
+}
+
+// localClient is an HTTP client which connects only to loopback addresses.
+var localClient = &http.Client{
+	Transport: &http.Transport{DialContext: dialLocal},
+	Timeout:   10 * time.Second,
+}
+
+// dialLocal connects to the address if its host is a loopback IP address.
+func dialLocal(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return nil, fmt.Errorf("filepickertest: refusing to connect to non-local address %s", addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// metadata returns all metadata the fake service knows about the file.
+func metadata(file *File) filepicker.Metadata {
+	md5sum, sha256sum := md5.Sum(file.Data), sha256.Sum256(file.Data)
+	sha224sum, sha384sum, sha512sum := sha256.Sum224(file.Data), sha512.Sum384(file.Data), sha512.Sum512(file.Data)
+	return filepicker.Metadata{
+		string(filepicker.TagSize):      float64(len(file.Data)),
+		string(filepicker.TagMimetype):  file.Mimetype,
+		string(filepicker.TagFilename):  file.Filename,
+		string(filepicker.TagUploaded):  float64(file.Uploaded.UnixNano() / int64(time.Millisecond)),
+		string(filepicker.TagWriteable): true,
+		string(filepicker.TagMd5Hash):   hex.EncodeToString(md5sum[:]),
+		string(filepicker.TagSha224):    hex.EncodeToString(sha224sum[:]),
+		string(filepicker.TagSha256):    hex.EncodeToString(sha256sum[:]),
+		string(filepicker.TagSha384):    hex.EncodeToString(sha384sum[:]),
+		string(filepicker.TagSha512):    hex.EncodeToString(sha512sum[:]),
+		string(filepicker.TagLocation):  string(file.Location),
+		string(filepicker.TagPath):      file.Path,
+		string(filepicker.TagContainer): file.Container,
+	}
+}
+
+// writeJSON sends the value encoded as JSON.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Cannot encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
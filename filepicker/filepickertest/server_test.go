@@ -0,0 +1,310 @@
+package filepickertest_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+	"github.com/filepicker/filepicker-go/filepicker/filepickertest"
+)
+
+const (
+	FakeApiKey = "0KKK1"
+	FakeSecret = "Z3IYZSH2UJA7VN3QYFVSVCF7PI"
+	Content    = "FILEPICKERTEST"
+)
+
+func TestServerRoundTrip(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	client := srv.Client()
+
+	blob := roundTripStore(t, client)
+	roundTripStat(t, client, blob)
+	roundTripWrite(t, client, blob)
+	roundTripConvert(t, client, blob)
+
+	if err := client.Remove(blob, nil); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if _, ok := srv.File(blob.Handle()); ok {
+		t.Error("want file removed; got ok")
+	}
+	if _, err := client.Stat(blob, nil); err == nil {
+		t.Error("want err != nil; got nil")
+	}
+	if n := srv.Len(); n != 1 {
+		t.Errorf("want srv.Len() == 1; got %d", n)
+	}
+}
+
+func roundTripStore(t *testing.T, client *filepicker.Client) *filepicker.Blob {
+	blob, err := client.StoreReader("file.txt", strings.NewReader(Content), &filepicker.StoreOpts{
+		Path: "docs/",
+	})
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	if blob.Filename != "file.txt" || blob.Size != uint64(len(Content)) || blob.Path != "docs/" {
+		t.Errorf("want blob of stored file; got %+v", blob)
+	}
+	return blob
+}
+
+func roundTripStat(t *testing.T, client *filepicker.Client, blob *filepicker.Blob) {
+	meta, err := client.Stat(blob, &filepicker.StatOpts{
+		Tags: []filepicker.MetaTag{filepicker.TagSize, filepicker.TagFilename},
+	})
+	if err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if size, _ := meta.Size(); size != uint64(len(Content)) {
+		t.Errorf("want size == %d; got %d", len(Content), size)
+	}
+	if _, ok := meta.Mimetype(); ok || len(meta) != 2 {
+		t.Errorf("want only requested tags; got %v", meta)
+	}
+}
+
+func roundTripWrite(t *testing.T, client *filepicker.Client, blob *filepicker.Blob) {
+	if _, err := client.WriteReader(blob, strings.NewReader("NEW"), nil); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	var buff bytes.Buffer
+	if _, err := client.DownloadTo(blob, &filepicker.DownloadOpts{Verify: filepicker.VerifyMD5}, &buff); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if buff.String() != "NEW" {
+		t.Errorf("want content == %q; got %q", "NEW", buff.String())
+	}
+}
+
+func roundTripConvert(t *testing.T, client *filepicker.Client, blob *filepicker.Blob) {
+	converted, err := client.ConvertAndStore(blob, &filepicker.ConvertOpts{
+		Width:    100,
+		Location: filepicker.Azure,
+	})
+	if err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if converted.Container != filepicker.Azure || converted.Handle() == blob.Handle() {
+		t.Errorf("want converted copy in azure; got %+v", converted)
+	}
+}
+
+func TestServerStoreURL(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(Content))
+	}))
+	defer origin.Close()
+
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	client := srv.Client()
+
+	for i, call := range []func(string) (*filepicker.Blob, error){
+		func(dataURL string) (*filepicker.Blob, error) { return client.StoreURL(dataURL, nil) },
+		func(dataURL string) (*filepicker.Blob, error) { return client.PickURL(dataURL, nil) },
+	} {
+		blob, err := call(origin.URL + "/image.txt")
+		if err != nil {
+			t.Fatalf("want err == nil; got %v (i:%d)", err, i)
+		}
+		file, ok := srv.File(blob.Handle())
+		if !ok || string(file.Data) != Content || file.Filename != "image.txt" {
+			t.Errorf("want stored file; got %+v (i:%d)", file, i)
+		}
+	}
+}
+
+func TestServerStoreURLError(t *testing.T) {
+	origin := httptest.NewServer(http.NotFoundHandler())
+	defer origin.Close()
+
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	client := srv.Client()
+
+	for i, dataURL := range []string{
+		origin.URL + "/missing.txt",
+		"http://192.0.2.1/image.txt",
+	} {
+		_, err := client.StoreURL(dataURL, nil)
+		if fperr, ok := err.(filepicker.Fperror); !ok || fperr.Code != http.StatusBadRequest {
+			t.Errorf("want 400 error; got %v (i:%d)", err, i)
+		}
+	}
+	if n := srv.Len(); n != 0 {
+		t.Errorf("want srv.Len() == 0; got %d", n)
+	}
+}
+
+func TestServerInvalidKey(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	client := filepicker.NewClient("invalid")
+	client.BaseURL = srv.Client().BaseURL
+
+	_, err := client.StoreReader("file.txt", strings.NewReader(Content), nil)
+	if fperr, ok := err.(filepicker.Fperror); !ok || fperr.Code != http.StatusForbidden {
+		t.Errorf("want 403 error; got %v", err)
+	}
+	if n := srv.Len(); n != 0 {
+		t.Errorf("want srv.Len() == 0; got %d", n)
+	}
+}
+
+func TestServerSecurity(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	srv.Secret = FakeSecret
+	defer srv.Close()
+	client := srv.Client()
+	blob := srv.Put("file.txt", []byte(Content))
+
+	security := func(opt *filepicker.PolicyOpts) filepicker.Security {
+		policy, err := filepicker.MakePolicy(opt)
+		if err != nil {
+			t.Fatalf("want err == nil; got %v", err)
+		}
+		return filepicker.MakeSecurity(FakeSecret, policy)
+	}
+	expiry := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		Security filepicker.Security
+		Ok       bool
+	}{
+		{
+			Security: filepicker.Security{},
+			Ok:       false,
+		},
+		{
+			Security: security(&filepicker.PolicyOpts{Expiry: expiry}),
+			Ok:       true,
+		},
+		{
+			Security: security(&filepicker.PolicyOpts{
+				Expiry: expiry,
+				Handle: blob.Handle(),
+				Call:   []filepicker.Method{filepicker.MetStat},
+			}),
+			Ok: true,
+		},
+		{
+			Security: security(&filepicker.PolicyOpts{
+				Expiry: expiry,
+				Call:   []filepicker.Method{filepicker.MetRead},
+			}),
+			Ok: false,
+		},
+		{
+			Security: security(&filepicker.PolicyOpts{
+				Expiry: expiry,
+				Handle: "XXXXXXXXXXXXXXXXXXXX",
+			}),
+			Ok: false,
+		},
+		{
+			Security: security(&filepicker.PolicyOpts{Expiry: time.Now().Add(-time.Hour)}),
+			Ok:       false,
+		},
+		{
+			Security: filepicker.MakeSecurity("wrong", security(&filepicker.PolicyOpts{Expiry: expiry}).Policy),
+			Ok:       false,
+		},
+	}
+
+	for i, test := range tests {
+		_, err := client.Stat(blob, &filepicker.StatOpts{Security: test.Security})
+		if ok := err == nil; ok != test.Ok {
+			t.Errorf("want ok == %t; got %v (i:%d)", test.Ok, err, i)
+		}
+	}
+}
+
+func TestServerPolicySize(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	srv.Secret = FakeSecret
+	defer srv.Close()
+	client := srv.Client()
+
+	policy, err := filepicker.MakePolicy(&filepicker.PolicyOpts{
+		Expiry:  time.Now().Add(time.Hour),
+		Call:    []filepicker.Method{filepicker.MetStore},
+		MaxSize: 4,
+	})
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	opt := &filepicker.StoreOpts{Security: filepicker.MakeSecurity(FakeSecret, policy)}
+	if _, err := client.StoreReader("small.txt", strings.NewReader("ABCD"), opt); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if _, err := client.StoreReader("large.txt", strings.NewReader("ABCDE"), opt); err == nil {
+		t.Error("want err != nil; got nil")
+	}
+}
+
+func TestServerPolicyRestrictions(t *testing.T) {
+	data := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(Content))
+	}))
+	defer data.Close()
+	srv := filepickertest.NewServer(FakeApiKey)
+	srv.Secret = FakeSecret
+	defer srv.Close()
+	client := srv.Client()
+	blob := srv.Put("file.txt", []byte(Content))
+
+	security := func(opt filepicker.PolicyOpts) filepicker.Security {
+		opt.Expiry = time.Now().Add(time.Hour)
+		policy, err := filepicker.MakePolicy(&opt)
+		if err != nil {
+			t.Fatalf("want err == nil; got %v", err)
+		}
+		return filepicker.MakeSecurity(FakeSecret, policy)
+	}
+	store := func(sec filepicker.Security) error {
+		_, err := client.StoreReader("file.txt", strings.NewReader(Content), &filepicker.StoreOpts{Security: sec})
+		return err
+	}
+	storeURL := func(sec filepicker.Security) error {
+		_, err := client.StoreURL(data.URL+"/file.txt", &filepicker.StoreOpts{Security: sec})
+		return err
+	}
+	stat := func(sec filepicker.Security) error {
+		_, err := client.Stat(blob, &filepicker.StatOpts{Security: sec})
+		return err
+	}
+	convert := func(sec filepicker.Security) error {
+		_, err := client.ConvertAndStore(blob, &filepicker.ConvertOpts{Width: 200, Security: sec})
+		return err
+	}
+
+	tests := []struct {
+		Opt  filepicker.PolicyOpts
+		Call func(filepicker.Security) error
+		Ok   bool
+	}{
+		{filepicker.PolicyOpts{Mimetypes: []string{"text/plain"}}, store, true},
+		{filepicker.PolicyOpts{Mimetypes: []string{"image/*"}}, store, false},
+		{filepicker.PolicyOpts{URL: regexp.QuoteMeta(data.URL) + "/.*"}, storeURL, true},
+		{filepicker.PolicyOpts{URL: `https://example\.com/.*`}, storeURL, false},
+		{filepicker.PolicyOpts{IP: []string{"127.0.0.0/8", "::1"}}, stat, true},
+		{filepicker.PolicyOpts{IP: []string{"10.0.0.0/8"}}, stat, false},
+		{filepicker.PolicyOpts{MaxWidth: 200}, convert, true},
+		{filepicker.PolicyOpts{MaxWidth: 100}, convert, false},
+	}
+
+	for i, test := range tests {
+		err := test.Call(security(test.Opt))
+		if ok := err == nil; ok != test.Ok {
+			t.Errorf("want ok == %t; got %v (i:%d)", test.Ok, err, i)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package filepicker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Instrumentation observes the calls made by a Client, for example in order to
+// record traces or metrics. Implementations must be safe for concurrent use.
+type Instrumentation interface {
+	// Start is called before a call is sent. The returned context is used
+	// for the rest of the call and is passed to End, so it can carry a span.
+	Start(ctx context.Context, ev *StartEvent) context.Context
+
+	// End is called when the response headers are received or the call
+	// fails. Reading the response body is not included in the call duration.
+	End(ctx context.Context, ev *EndEvent)
+}
+
+// StartEvent describes a call that is about to be sent.
+type StartEvent struct {
+	// Call identifies the method, the handle and the options of the call.
+	Call *Call
+
+	// Storage is the file store the call puts files in. It is empty for
+	// calls that do not store files.
+	Storage Storage
+
+	// Size is the length of the request body, -1 if unknown.
+	Size int64
+}
+
+// EndEvent describes the outcome of a call.
+type EndEvent struct {
+	// Call identifies the method, the handle and the options of the call.
+	Call *Call
+
+	// Status is the status code of the response, zero if there is none.
+	Status int
+
+	// Size is the length of the response body, -1 if unknown.
+	Size int64
+
+	// Duration is the time elapsed since the start of the call.
+	Duration time.Duration
+
+	// Err is the reason of failure, either a transport error or an error
+	// response of the service. It is nil for successful calls.
+	Err error
+
+	// Class is the category of Err.
+	Class ErrorClass
+}
+
+// ErrorClass is a coarse category of errors returned by Client methods, which
+// is suitable as a label of metrics.
+type ErrorClass string
+
+// Error classes reported by Classify function.
+const (
+	ClassNone          = ErrorClass("")
+	ClassCanceled      = ErrorClass("canceled")
+	ClassTimeout       = ErrorClass("timeout")
+	ClassNotFound      = ErrorClass("not_found")
+	ClassPolicyExpired = ErrorClass("policy_expired")
+	ClassUnauthorized  = ErrorClass("unauthorized")
+	ClassQuotaExceeded = ErrorClass("quota_exceeded")
+	ClassRateLimited   = ErrorClass("rate_limited")
+	ClassTooLarge      = ErrorClass("too_large")
+	ClassChecksum      = ErrorClass("checksum")
+	ClassRange         = ErrorClass("range")
+	ClassClient        = ErrorClass("client")
+	ClassServer        = ErrorClass("server")
+	ClassTransport     = ErrorClass("transport")
+	ClassOther         = ErrorClass("other")
+)
+
+// errorClasses maps the sentinel errors to their classes. ErrPolicyExpired
+// precedes ErrUnauthorized since expired policy errors match both.
+var errorClasses = []struct {
+	err   error
+	class ErrorClass
+}{
+	{context.Canceled, ClassCanceled},
+	{context.DeadlineExceeded, ClassTimeout},
+	{ErrNotFound, ClassNotFound},
+	{ErrPolicyExpired, ClassPolicyExpired},
+	{ErrUnauthorized, ClassUnauthorized},
+	{ErrQuotaExceeded, ClassQuotaExceeded},
+	{ErrRateLimited, ClassRateLimited},
+	{ErrTooLarge, ClassTooLarge},
+}
+
+// Classify returns the category of the error. It returns ClassNone for nil.
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ClassNone
+	}
+	for _, ec := range errorClasses {
+		if errors.Is(err, ec.err) {
+			return ec.class
+		}
+	}
+	var fperr Fperror
+	var netErr net.Error
+	switch {
+	case errors.As(err, &fperr) && fperr.Code >= 500:
+		return ClassServer
+	case errors.As(err, &fperr):
+		return ClassClient
+	case errors.As(err, &ChecksumError{}):
+		return ClassChecksum
+	case errors.As(err, &RangeError{}):
+		return ClassRange
+	case errors.As(err, &netErr):
+		return ClassTransport
+	}
+	return ClassOther
+}
+
+// instrument reports the start of the call to the client's Instrumentation. It
+// returns the request bound to the context given by the instrumentation and a
+// function which reports the end of the call.
+func (c *Client) instrument(call *Call, req *http.Request) (*http.Request, func(*http.Response, error)) {
+	if c.Instrumentation == nil {
+		return req, func(*http.Response, error) {}
+	}
+	start := time.Now()
+	ctx := c.Instrumentation.Start(req.Context(), &StartEvent{
+		Call:    call,
+		Storage: c.storageOf(call),
+		Size:    req.ContentLength,
+	})
+	return req.WithContext(ctx), func(resp *http.Response, err error) {
+		ev := &EndEvent{Call: call, Size: -1, Duration: time.Since(start), Err: err}
+		if resp != nil {
+			ev.Status, ev.Size = resp.StatusCode, resp.ContentLength
+			if err == nil {
+				ev.Err = peekError(resp)
+			}
+		}
+		ev.Class = Classify(ev.Err)
+		c.Instrumentation.End(ctx, ev)
+	}
+}
+
+// storageOf returns the file store the call puts files in.
+func (c *Client) storageOf(call *Call) Storage {
+	switch opt := call.Opts.(type) {
+	case *StoreOpts:
+		if opt != nil && opt.Location != "" {
+			return opt.Location
+		}
+		return c.storage
+	case *ConvertOpts:
+		if opt != nil && opt.Location != "" {
+			return opt.Location
+		}
+		return c.storage
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+package filepicker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+	"github.com/filepicker/filepicker-go/filepicker/filepickertest"
+)
+
+type spanKey struct{}
+
+type recordingInstrumentation struct {
+	mu     sync.Mutex
+	starts []filepicker.StartEvent
+	ends   []filepicker.EndEvent
+	spans  []interface{}
+}
+
+func (ri *recordingInstrumentation) Start(ctx context.Context, ev *filepicker.StartEvent) context.Context {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	ri.starts = append(ri.starts, *ev)
+	return context.WithValue(ctx, spanKey{}, len(ri.starts))
+}
+
+func (ri *recordingInstrumentation) End(ctx context.Context, ev *filepicker.EndEvent) {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	ri.ends = append(ri.ends, *ev)
+	ri.spans = append(ri.spans, ctx.Value(spanKey{}))
+}
+
+func TestInstrumentation(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	client := srv.Client()
+	ri := &recordingInstrumentation{}
+	client.Instrumentation = ri
+	var spans []interface{}
+	client.Middleware = []filepicker.Middleware{
+		func(next filepicker.RoundTripFunc) filepicker.RoundTripFunc {
+			return func(call *filepicker.Call, req *http.Request) (*http.Response, error) {
+				spans = append(spans, req.Context().Value(spanKey{}))
+				return next(call, req)
+			}
+		},
+	}
+
+	blob, err := client.StoreReader("a.txt", strings.NewReader("ABC"), &filepicker.StoreOpts{Location: filepicker.Azure})
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	client.Stat(blob, nil)
+	client.Remove(srv.Blob("XXXXXXXXXXXXXXXXXXXX"), nil)
+
+	tests := []wantEvent{
+		{filepicker.MetStore, "", filepicker.Azure, http.StatusOK, filepicker.ClassNone},
+		{filepicker.MetStat, blob.Handle(), "", http.StatusOK, filepicker.ClassNone},
+		{filepicker.MetRemove, "XXXXXXXXXXXXXXXXXXXX", "", http.StatusNotFound, filepicker.ClassNotFound},
+	}
+	if len(ri.starts) != len(tests) || len(ri.ends) != len(tests) {
+		t.Fatalf("want %d events; got %d, %d", len(tests), len(ri.starts), len(ri.ends))
+	}
+	for i, test := range tests {
+		checkEvents(t, ri.starts[i], ri.ends[i], test, i)
+		if ri.spans[i] != i+1 || spans[i] != i+1 {
+			t.Errorf("want span %d; got %v, %v (i:%d)", i+1, ri.spans[i], spans[i], i)
+		}
+	}
+	if size := ri.starts[0].Size; size <= 3 {
+		t.Errorf("want size of multipart body; got %d", size)
+	}
+}
+
+// wantEvent describes the expected start and end events of a call.
+type wantEvent struct {
+	Method  filepicker.Method
+	Handle  string
+	Storage filepicker.Storage
+	Status  int
+	Class   filepicker.ErrorClass
+}
+
+func checkEvents(t *testing.T, start filepicker.StartEvent, end filepicker.EndEvent, test wantEvent, i int) {
+	if start.Call.Method != test.Method || start.Call.Handle != test.Handle || start.Storage != test.Storage {
+		t.Errorf("want start of %s %q in %q; got %+v, %q (i:%d)", test.Method, test.Handle, test.Storage, *start.Call, start.Storage, i)
+	}
+	if end.Call != start.Call || end.Status != test.Status || end.Class != test.Class {
+		t.Errorf("want end with %d %q; got %d %q (i:%d)", test.Status, test.Class, end.Status, end.Class, i)
+	}
+	if (end.Err == nil) != (test.Class == filepicker.ClassNone) || end.Duration <= 0 {
+		t.Errorf("want err and duration; got %v, %v (i:%d)", end.Err, end.Duration, i)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		Err   error
+		Class filepicker.ErrorClass
+	}{
+		{nil, filepicker.ClassNone},
+		{context.Canceled, filepicker.ClassCanceled},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), filepicker.ClassTimeout},
+		{filepicker.Fperror{Code: 404}, filepicker.ClassNotFound},
+		{filepicker.Fperror{Code: 403, Message: "Policy expired"}, filepicker.ClassPolicyExpired},
+		{filepicker.Fperror{Code: 403}, filepicker.ClassUnauthorized},
+		{filepicker.Fperror{Code: 429}, filepicker.ClassRateLimited},
+		{filepicker.Fperror{Code: 402}, filepicker.ClassQuotaExceeded},
+		{filepicker.Fperror{Code: 413}, filepicker.ClassTooLarge},
+		{filepicker.Fperror{Code: 503}, filepicker.ClassServer},
+		{filepicker.Fperror{Code: 400}, filepicker.ClassClient},
+		{filepicker.ChecksumError{Tag: filepicker.TagMd5Hash}, filepicker.ClassChecksum},
+		{filepicker.RangeError{Size: 10}, filepicker.ClassRange},
+		{&net.OpError{Op: "dial", Err: errors.New("refused")}, filepicker.ClassTransport},
+		{errors.New(dummyErrStr), filepicker.ClassOther},
+	}
+
+	for i, test := range tests {
+		if class := filepicker.Classify(test.Err); class != test.Class {
+			t.Errorf("want class == %q; got %q (i:%d)", test.Class, class, i)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package filepicker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Key is a named application secret used to sign and verify policies.
+type Key struct {
+	// Name identifies the key. It is never sent to filepicker service.
+	Name string
+
+	// Secret is the application secret taken from the developer portal.
+	Secret string
+
+	// ActiveFrom is the time after which the key is used for signing. Zero
+	// value means that the key is active immediately.
+	ActiveFrom time.Time
+
+	// Expires is the time after which the key is no longer used for signing
+	// nor verifying. Zero value means that the key never expires.
+	Expires time.Time
+}
+
+// active reports whether the key may be used for signing at the given time.
+func (k Key) active(now time.Time) bool {
+	return !now.Before(k.ActiveFrom) && k.valid(now)
+}
+
+// valid reports whether the key may be used for verifying at the given time.
+func (k Key) valid(now time.Time) bool {
+	return k.Expires.IsZero() || now.Before(k.Expires)
+}
+
+// Keyring holds the application secrets which are valid during the rotation
+// of the secret. New policies are signed with the most recently activated key
+// while policies signed with any key that has not expired pass verification.
+// This way a key can be distributed to verifiers before it is used for
+// signing. Keyring implements Signer interface and it is safe for concurrent
+// use.
+type Keyring struct {
+	mu   sync.RWMutex
+	keys []Key
+}
+
+// NewKeyring creates a keyring which holds the provided keys.
+func NewKeyring(keys ...Key) *Keyring {
+	return &Keyring{keys: append([]Key(nil), keys...)}
+}
+
+// Add puts the key to the keyring. A key with the same name is replaced.
+func (kr *Keyring) Add(key Key) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	for i := range kr.keys {
+		if kr.keys[i].Name == key.Name {
+			kr.keys[i] = key
+			return
+		}
+	}
+	kr.keys = append(kr.keys, key)
+}
+
+// Keys returns a copy of the keys stored in the keyring.
+func (kr *Keyring) Keys() []Key {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return append([]Key(nil), kr.keys...)
+}
+
+// Current returns the key that is used for signing, which is the most
+// recently activated key that has not expired. It returns false if there is
+// no such key.
+func (kr *Keyring) Current() (current Key, ok bool) {
+	now := time.Now()
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	for _, key := range kr.keys {
+		if key.active(now) && (!ok || key.ActiveFrom.After(current.ActiveFrom)) {
+			current, ok = key, true
+		}
+	}
+	return
+}
+
+// Sign implements Signer interface. It signs the policy with the current key
+// and returns ErrNoKey if there is none.
+func (kr *Keyring) Sign(po *PolicyOpts) (Security, error) {
+	key, ok := kr.Current()
+	if !ok {
+		return Security{}, ErrNoKey
+	}
+	policy, err := MakePolicy(po)
+	if err != nil {
+		return Security{}, err
+	}
+	return MakeSecurity(key.Secret, policy), nil
+}
+
+// Verify checks whether the security parameters were signed with any key
+// that has not expired. It returns the name of the matching key.
+func (kr *Keyring) Verify(sec Security) (name string, ok bool) {
+	now := time.Now()
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	for _, key := range kr.keys {
+		if key.valid(now) && sec.Verify(key.Secret) {
+			return key.Name, true
+		}
+	}
+	return "", false
+}
+
+// ParseKeyring reads keys from r. Each line of the input describes one key
+// with whitespace separated fields:
+//
+//	name secret [active-from [expires]]
+//
+// Times are written in RFC 3339 format, a single dash stands for zero time.
+// Empty lines and lines starting with '#' are ignored.
+func ParseKeyring(r io.Reader) (*Keyring, error) {
+	kr := NewKeyring()
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := parseKey(strings.Fields(line))
+		if err != nil {
+			return nil, fmt.Errorf("filepicker: keyring line %d: %v", n, err)
+		}
+		kr.Add(key)
+	}
+	return kr, scanner.Err()
+}
+
+// LoadKeyringFile reads keys from the named file. See ParseKeyring for the
+// description of the format.
+func LoadKeyringFile(name string) (*Keyring, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseKeyring(file)
+}
+
+// LoadKeyringEnv reads keys from the environment variable with the given
+// name. The variable uses the format of ParseKeyring in which lines can also
+// be separated with semicolons.
+func LoadKeyringEnv(name string) (*Keyring, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("filepicker: environment variable %s is not set", name)
+	}
+	return ParseKeyring(strings.NewReader(strings.Replace(value, ";", "\n", -1)))
+}
+
+// parseKey creates a key from the fields of a keyring line.
+func parseKey(fields []string) (key Key, err error) {
+	if len(fields) < 2 || len(fields) > 4 {
+		return key, fmt.Errorf("want 2 to 4 fields; got %d", len(fields))
+	}
+	key.Name, key.Secret = fields[0], fields[1]
+	times := []*time.Time{&key.ActiveFrom, &key.Expires}
+	for i, field := range fields[2:] {
+		if field == "-" {
+			continue
+		}
+		if *times[i], err = time.Parse(time.RFC3339, field); err != nil {
+			return key, err
+		}
+	}
+	return key, nil
+}
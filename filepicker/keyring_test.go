@@ -0,0 +1,180 @@
+package filepicker_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+// rotatingKeyring creates a keyring with an old key that is being retired,
+// the current key, the next key and an expired one.
+func rotatingKeyring(now time.Time) *filepicker.Keyring {
+	return filepicker.NewKeyring(
+		filepicker.Key{Name: "old", Secret: "OLD", Expires: now.Add(time.Hour)},
+		filepicker.Key{Name: "cur", Secret: "CUR", ActiveFrom: now.Add(-time.Minute)},
+		filepicker.Key{Name: "next", Secret: "NEXT", ActiveFrom: now.Add(time.Hour)},
+		filepicker.Key{Name: "gone", Secret: "GONE", Expires: now.Add(-time.Minute)},
+	)
+}
+
+func TestKeyringRotation(t *testing.T) {
+	now := time.Now()
+	kr := rotatingKeyring(now)
+
+	if key, ok := kr.Current(); !ok || key.Name != "cur" {
+		t.Errorf("want current key == cur; got %q, %t", key.Name, ok)
+	}
+	sec, err := kr.Sign(&filepicker.PolicyOpts{Expiry: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	if !sec.Verify("CUR") {
+		t.Errorf("want policy signed with the current key; got %+v", sec)
+	}
+
+	kr.Add(filepicker.Key{Name: "cur", Secret: "CUR", Expires: now})
+	if key, ok := kr.Current(); !ok || key.Name != "old" {
+		t.Errorf("want current key == old; got %q, %t", key.Name, ok)
+	}
+	if n := len(kr.Keys()); n != 4 {
+		t.Errorf("want 4 keys; got %d", n)
+	}
+}
+
+func TestKeyringVerify(t *testing.T) {
+	now := time.Now()
+	kr := rotatingKeyring(now)
+	sec, err := kr.Sign(&filepicker.PolicyOpts{Expiry: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+
+	tests := []struct {
+		Secret string
+		Name   string
+		Ok     bool
+	}{
+		{"OLD", "old", true},
+		{"CUR", "cur", true},
+		{"NEXT", "next", true},
+		{"GONE", "", false},
+		{"OTHER", "", false},
+	}
+	for i, test := range tests {
+		sec := filepicker.MakeSecurity(test.Secret, sec.Policy)
+		name, ok := kr.Verify(sec)
+		if name != test.Name || ok != test.Ok {
+			t.Errorf("want %q, %t; got %q, %t (i:%d)", test.Name, test.Ok, name, ok, i)
+		}
+	}
+}
+
+func TestKeyringNoKey(t *testing.T) {
+	kr := filepicker.NewKeyring(filepicker.Key{Name: "next", Secret: "NEXT", ActiveFrom: time.Now().Add(time.Hour)})
+	_, err := kr.Sign(&filepicker.PolicyOpts{Expiry: time.Now().Add(time.Minute)})
+	if err != filepicker.ErrNoKey {
+		t.Errorf("want err == ErrNoKey; got %v", err)
+	}
+}
+
+func TestKeyringSigner(t *testing.T) {
+	var signer filepicker.Signer = filepicker.NewKeyring(filepicker.Key{Name: "cur", Secret: FakeSecret})
+	sec, err := signer.Sign(&filepicker.PolicyOpts{Expiry: time.Now().Add(time.Minute)})
+	if err != nil || !sec.Verify(FakeSecret) {
+		t.Errorf("want valid signature; got %+v, %v", sec, err)
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	tests := []struct {
+		Input string
+		Keys  []filepicker.Key
+		Ok    bool
+	}{
+		{
+			Input: "# comment\n\nold OLD - 2017-10-16T08:00:00Z\nnew NEW 2017-10-15T08:00:00Z\n",
+			Keys: []filepicker.Key{
+				{Name: "old", Secret: "OLD", Expires: time.Date(2017, 10, 16, 8, 0, 0, 0, time.UTC)},
+				{Name: "new", Secret: "NEW", ActiveFrom: time.Date(2017, 10, 15, 8, 0, 0, 0, time.UTC)},
+			},
+			Ok: true,
+		},
+		{
+			Input: "default SECRET",
+			Keys:  []filepicker.Key{{Name: "default", Secret: "SECRET"}},
+			Ok:    true,
+		},
+		{
+			Input: "lonely",
+			Ok:    false,
+		},
+		{
+			Input: "bad SECRET yesterday",
+			Ok:    false,
+		},
+	}
+
+	for i, test := range tests {
+		kr, err := filepicker.ParseKeyring(strings.NewReader(test.Input))
+		if ok := err == nil; ok != test.Ok {
+			t.Errorf("want ok == %t; got %v (i:%d)", test.Ok, err, i)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		keys := kr.Keys()
+		if len(keys) != len(test.Keys) {
+			t.Errorf("want %d keys; got %d (i:%d)", len(test.Keys), len(keys), i)
+			continue
+		}
+		for j, key := range keys {
+			want := test.Keys[j]
+			if key.Name != want.Name || key.Secret != want.Secret ||
+				!key.ActiveFrom.Equal(want.ActiveFrom) || !key.Expires.Equal(want.Expires) {
+				t.Errorf("want key == %+v; got %+v (i:%d)", want, key, i)
+			}
+		}
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyring")
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "keys")
+	if err := ioutil.WriteFile(name, []byte("a A\nb B\n"), 0600); err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	os.Setenv("FILEPICKER_TEST_KEYS", "a A;b B")
+	defer os.Unsetenv("FILEPICKER_TEST_KEYS")
+
+	for i, load := range []func() (*filepicker.Keyring, error){
+		func() (*filepicker.Keyring, error) { return filepicker.LoadKeyringFile(name) },
+		func() (*filepicker.Keyring, error) { return filepicker.LoadKeyringEnv("FILEPICKER_TEST_KEYS") },
+	} {
+		kr, err := load()
+		if err != nil {
+			t.Errorf("want err == nil; got %v (i:%d)", err, i)
+			continue
+		}
+		if n := len(kr.Keys()); n != 2 {
+			t.Errorf("want 2 keys; got %d (i:%d)", n, i)
+		}
+	}
+
+	if _, err := filepicker.LoadKeyringEnv("FILEPICKER_TEST_UNSET"); err == nil {
+		t.Error("want err != nil; got nil")
+	}
+	if _, err := filepicker.LoadKeyringFile(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want errors.Is(err, os.ErrNotExist) == true; got %v", err)
+	}
+}
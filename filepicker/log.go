@@ -0,0 +1,203 @@
+package filepicker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Logger receives structured records of the calls made by a Client. Each
+// record consists of a message and alternating keys and values. The methods
+// match those of *slog.Logger, so it can be used directly. Implementations
+// must be safe for concurrent use.
+type Logger interface {
+	// Info logs a call that succeeded.
+	Info(msg string, keyvals ...interface{})
+
+	// Error logs a call that failed, either with a transport error, with an
+	// error response of the service or with a malformed response.
+	Error(msg string, keyvals ...interface{})
+}
+
+// callLog collects the record of a call until the outcome of the call is
+// known. The values of sensitive query parameters, like the API key and the
+// signature, are masked in the logged URL and error. The record contains the
+// following keys:
+//
+//	op        the method of the call, like "stat"
+//	handle    the handle of the accessed file, if any
+//	method    the HTTP method of the request
+//	url       the redacted URL of the request
+//	sent      the number of bytes of request body sent, including retries
+//	duration  the time spent on the call, including retries and the reading
+//	          of response body
+//	retries   the number of retries
+//	status    the status code of the response, unless there is none
+//	received  the number of bytes of response body read
+//	error     the reason of failure, only for failed calls
+//
+// A call that received a response is logged when the response body is closed,
+// so that the failures found while reading the body, like a malformed content,
+// are logged as well.
+type callLog struct {
+	sent     int64
+	received int64
+
+	logger  Logger
+	req     *http.Request
+	resp    *http.Response
+	start   time.Time
+	retries int
+
+	mu   sync.Mutex
+	err  error
+	once sync.Once
+}
+
+// logKey is the context key under which the log of a call is stored.
+type logKey struct{}
+
+// track returns a copy of the request which carries the log in its context and
+// whose body, including the bodies created to retry the request, counts the
+// bytes sent.
+func (l *callLog) track(req *http.Request) *http.Request {
+	req = req.WithContext(context.WithValue(req.Context(), logKey{}, l))
+	l.req = req
+	if req.Body == nil || req.Body == http.NoBody {
+		return req
+	}
+	req.Body = countingBody{req.Body, &l.sent}
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return countingBody{body, &l.sent}, nil
+		}
+	}
+	return req
+}
+
+// finish records the outcome of the request. A failed request is logged at
+// once, otherwise the returned response has its body replaced with the one
+// which logs the call when it is closed.
+func (l *callLog) finish(resp *http.Response, retries int, err error) *http.Response {
+	l.resp, l.retries = resp, retries
+	if err != nil || resp == nil {
+		l.fail(err)
+		l.write()
+		return resp
+	}
+	l.fail(peekError(resp))
+	resp.Body = loggedBody{countingBody{resp.Body, &l.received}, l}
+	return resp
+}
+
+// fail records the error which made the call fail. Only the first error is
+// kept.
+func (l *callLog) fail(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+// write passes the record to the logger. It does nothing if the call has been
+// logged already.
+func (l *callLog) write() {
+	l.once.Do(func() {
+		call := callOf(l.req.Context())
+		keyvals := []interface{}{
+			"op", string(call.Method),
+			"handle", call.Handle,
+			"method", l.req.Method,
+			"url", redactURL(l.req.URL),
+			"sent", atomic.LoadInt64(&l.sent),
+			"duration", time.Since(l.start),
+			"retries", l.retries,
+		}
+		if l.resp != nil {
+			keyvals = append(keyvals, "status", l.resp.StatusCode, "received", atomic.LoadInt64(&l.received))
+		}
+		l.mu.Lock()
+		err := l.err
+		l.mu.Unlock()
+		if err != nil {
+			l.logger.Error("filepicker call failed", append(keyvals, "error", redactError(err, l.req))...)
+			return
+		}
+		l.logger.Info("filepicker call", keyvals...)
+	})
+}
+
+// failCall reports the error which made the call fail after its response had
+// been received, like a malformed response body, to the log of the call. It
+// returns err unchanged.
+func failCall(resp *http.Response, err error) error {
+	if resp.Request == nil {
+		return err
+	}
+	if log, ok := resp.Request.Context().Value(logKey{}).(*callLog); ok {
+		log.fail(err)
+	}
+	return err
+}
+
+// countingBody counts the bytes read from the body of a request or response.
+type countingBody struct {
+	io.ReadCloser
+	n *int64
+}
+
+// Read satisfies io.Reader interface.
+func (b countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(b.n, int64(n))
+	return n, err
+}
+
+// loggedBody is the response body which logs the call when it is closed. The
+// errors of reading the body are logged as failures of the call.
+type loggedBody struct {
+	countingBody
+	log *callLog
+}
+
+// Read satisfies io.Reader interface.
+func (b loggedBody) Read(p []byte) (int, error) {
+	n, err := b.countingBody.Read(p)
+	if err != nil && err != io.EOF {
+		b.log.fail(err)
+	}
+	return n, err
+}
+
+// Close satisfies io.Closer interface.
+func (b loggedBody) Close() error {
+	err := b.countingBody.Close()
+	b.log.write()
+	return err
+}
+
+// redactError returns the message of err with the URL of transport errors
+// masked like the logged URL of the request.
+func redactError(err error, req *http.Request) string {
+	msg := err.Error()
+	var uerr *url.Error
+	if !errors.As(err, &uerr) || uerr.URL == "" {
+		return msg
+	}
+	redacted := redactURL(req.URL)
+	if u, perr := url.Parse(uerr.URL); perr == nil {
+		redacted = redactURL(u)
+	}
+	return strings.Replace(msg, uerr.URL, redacted, -1)
+}
@@ -0,0 +1,202 @@
+package filepicker_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+type record struct {
+	Level  string
+	Msg    string
+	Fields map[string]interface{}
+}
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	records []record
+}
+
+func (l *recordingLogger) log(level, msg string, keyvals []interface{}) {
+	fields := make(map[string]interface{})
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fields[fmt.Sprint(keyvals[i])] = keyvals[i+1]
+	}
+	l.mu.Lock()
+	l.records = append(l.records, record{level, msg, fields})
+	l.mu.Unlock()
+}
+
+func (l *recordingLogger) Info(msg string, keyvals ...interface{}) {
+	l.log("info", msg, keyvals)
+}
+
+func (l *recordingLogger) Error(msg string, keyvals ...interface{}) {
+	l.log("error", msg, keyvals)
+}
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		Status  int
+		Body    string
+		Level   string
+		Retries int
+		Error   string
+	}{
+		{http.StatusOK, "success", "info", 0, ""},
+		{http.StatusNotFound, "File not found", "error", 0, "filepicker: 404 - File not found"},
+		{http.StatusServiceUnavailable, "Unavailable", "error", 2, "filepicker: 503 - Unavailable"},
+	}
+
+	for i, test := range tests {
+		handler := func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(test.Status)
+			w.Write([]byte(test.Body))
+		}
+		logger := &recordingLogger{}
+		client := filepicker.NewClient(FakeApiKey)
+		client.Logger = logger
+		client.Retry = &filepicker.RetryPolicy{MinBackoff: time.Millisecond}
+		mock := MockServer(t, client, handler)
+		client.Remove(filepicker.NewBlob(FakeHandle), &filepicker.RemoveOpts{Security: dummySecurity})
+		mock.Close()
+
+		if len(logger.records) != 1 {
+			t.Errorf("want 1 record; got %d (i:%d)", len(logger.records), i)
+			continue
+		}
+		rec := logger.records[0]
+		if rec.Level != test.Level {
+			t.Errorf("want level == %q; got %q (i:%d)", test.Level, rec.Level, i)
+		}
+		want := map[string]interface{}{
+			"op":      "remove",
+			"handle":  FakeHandle,
+			"method":  "DELETE",
+			"url":     "http://www.filepicker.io/api/file/2HHH3?key=REDACTED&policy=REDACTED&signature=REDACTED",
+			"status":  test.Status,
+			"retries": test.Retries,
+		}
+		for key, value := range want {
+			if rec.Fields[key] != value {
+				t.Errorf("want %s == %v; got %v (i:%d)", key, value, rec.Fields[key], i)
+			}
+		}
+		if errStr, _ := rec.Fields["error"].(string); errStr != test.Error {
+			t.Errorf("want error == %q; got %q (i:%d)", test.Error, errStr, i)
+		}
+		if _, ok := rec.Fields["duration"].(time.Duration); !ok {
+			t.Errorf("want duration; got %v (i:%d)", rec.Fields["duration"], i)
+		}
+	}
+}
+
+func TestLoggerTransportError(t *testing.T) {
+	logger := &recordingLogger{}
+	client := filepicker.NewClient(FakeApiKey)
+	client.Logger = logger
+	client.Client.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ioutil.ReadAll(req.Body)
+		return nil, errors.New(dummyErrStr)
+	})
+
+	client.StoreReader("a.txt", strings.NewReader("ABC"), nil)
+	if len(logger.records) != 1 {
+		t.Fatalf("want 1 record; got %d", len(logger.records))
+	}
+	rec := logger.records[0]
+	if sent, _ := rec.Fields["sent"].(int64); rec.Level != "error" || rec.Fields["op"] != "store" || sent <= 3 {
+		t.Errorf("want failed store record; got %+v", rec)
+	}
+	if _, ok := rec.Fields["status"]; ok {
+		t.Errorf("want no status; got %v", rec.Fields["status"])
+	}
+	if errStr, _ := rec.Fields["error"].(string); !strings.Contains(errStr, dummyErrStr) {
+		t.Errorf("want error containing %q; got %q", dummyErrStr, errStr)
+	}
+}
+
+func TestLoggerTransferred(t *testing.T) {
+	tests := []struct {
+		Response string
+		Received int64
+		Error    string
+	}{
+		{`{"url":"https://www.filepicker.io/api/file/2HHH3"}`, 50, ""},
+		{`{"url":`, 7, "unexpected EOF"},
+	}
+
+	for i, test := range tests {
+		var sent int64
+		handler := func(w http.ResponseWriter, req *http.Request) {
+			n, _ := io.Copy(ioutil.Discard, req.Body)
+			sent = n
+			w.Write([]byte(test.Response))
+		}
+		logger := &recordingLogger{}
+		client := filepicker.NewClient(FakeApiKey)
+		client.Logger = logger
+		mock := MockServer(t, client, handler)
+		client.StoreReader("a.txt", ioutil.NopCloser(strings.NewReader(storeFileContent)), nil)
+		mock.Close()
+
+		if len(logger.records) != 1 {
+			t.Errorf("want 1 record; got %d (i:%d)", len(logger.records), i)
+			continue
+		}
+		rec := logger.records[0]
+		if rec.Fields["sent"] != sent || rec.Fields["received"] != test.Received {
+			t.Errorf("want sent == %d, received == %d; got %v, %v (i:%d)", sent, test.Received, rec.Fields["sent"], rec.Fields["received"], i)
+		}
+		if errStr, _ := rec.Fields["error"].(string); errStr != test.Error {
+			t.Errorf("want error == %q; got %q (i:%d)", test.Error, errStr, i)
+		}
+	}
+}
+
+func TestLoggerDialError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	logger := &recordingLogger{}
+	client := filepicker.NewClient(FakeApiKey)
+	client.Logger = logger
+	blob := filepicker.NewBlobURL(&url.URL{Scheme: "http", Host: addr}, FakeHandle)
+	sec := filepicker.Security{Policy: "SECRETPOLICY", Signature: "SECRETSIGNATURE"}
+
+	if err := client.Remove(blob, &filepicker.RemoveOpts{Security: sec}); err == nil {
+		t.Fatal("want err != nil; got nil")
+	}
+	if len(logger.records) != 1 {
+		t.Fatalf("want 1 record; got %d", len(logger.records))
+	}
+	errStr, _ := logger.records[0].Fields["error"].(string)
+	if !strings.Contains(errStr, addr) || !strings.Contains(errStr, "REDACTED") {
+		t.Errorf("want error with redacted URL; got %q", errStr)
+	}
+	for _, secret := range []string{"key=" + FakeApiKey, "SECRETPOLICY", "SECRETSIGNATURE"} {
+		if strings.Contains(errStr, secret) {
+			t.Errorf("want error without %q; got %q", secret, errStr)
+		}
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -1,6 +1,7 @@
 package filepicker
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"path"
@@ -23,8 +24,31 @@ const (
 	TagLocation  = MetaTag("location")
 	TagPath      = MetaTag("path")
 	TagContainer = MetaTag("container")
+
+	TagSha224     = MetaTag("sha224")     // SHA-224 hash of the file.
+	TagSha256     = MetaTag("sha256")     // SHA-256 hash of the file.
+	TagSha384     = MetaTag("sha384")     // SHA-384 hash of the file.
+	TagSha512     = MetaTag("sha512")     // SHA-512 hash of the file.
+	TagExif       = MetaTag("exif")       // EXIF data of an image.
+	TagColorspace = MetaTag("colorspace") // Colour space of an image.
+	TagPages      = MetaTag("pages")      // Page count of a document.
+	TagDuration   = MetaTag("duration")   // Duration of audio or video.
 )
 
+// HashTags returns the tags of all hashes the service computes.
+func HashTags() []MetaTag {
+	return []MetaTag{TagMd5Hash, TagSha224, TagSha256, TagSha384, TagSha512}
+}
+
+// AllTags returns all metadata tags known to the package.
+func AllTags() []MetaTag {
+	return append([]MetaTag{
+		TagSize, TagMimetype, TagFilename, TagWidth, TagHeight, TagUploaded,
+		TagWriteable, TagLocation, TagPath, TagContainer, TagExif,
+		TagColorspace, TagPages, TagDuration,
+	}, HashTags()...)
+}
+
 // StatOpts TODO : (ppknap)
 type StatOpts struct {
 	// Tags TODO : (ppknap)
@@ -36,6 +60,12 @@ type StatOpts struct {
 	Security
 }
 
+// StatAll returns options which request all metadata tags known to the
+// package. Their Security field can be set before the call.
+func StatAll() *StatOpts {
+	return &StatOpts{Tags: AllTags()}
+}
+
 // toValues TODO : (ppknap)
 func (mo *StatOpts) toValues() url.Values {
 	values := toValues(*mo)
@@ -101,7 +131,7 @@ func (md Metadata) Height() (height uint64, ok bool) {
 func (md Metadata) Uploaded() (uploaded time.Time, ok bool) {
 	if val, ok := md[string(TagUploaded)]; ok && val != nil {
 		raw := int64(val.(float64))
-		return time.Unix(raw/1000, raw%1000), ok
+		return time.Unix(0, raw*int64(time.Millisecond)), ok
 	}
 	return
 }
@@ -151,8 +181,76 @@ func (md Metadata) Container() (container string, ok bool) {
 	return
 }
 
+// Sha224Hash returns the SHA-224 hash of the stored file. The second value (ok)
+// is set to false if the information is unavailable.
+func (md Metadata) Sha224Hash() (hash string, ok bool) {
+	return md.stringTag(TagSha224)
+}
+
+// Sha256Hash returns the SHA-256 hash of the stored file. The second value (ok)
+// is set to false if the information is unavailable.
+func (md Metadata) Sha256Hash() (hash string, ok bool) {
+	return md.stringTag(TagSha256)
+}
+
+// Sha384Hash returns the SHA-384 hash of the stored file. The second value (ok)
+// is set to false if the information is unavailable.
+func (md Metadata) Sha384Hash() (hash string, ok bool) {
+	return md.stringTag(TagSha384)
+}
+
+// Sha512Hash returns the SHA-512 hash of the stored file. The second value (ok)
+// is set to false if the information is unavailable.
+func (md Metadata) Sha512Hash() (hash string, ok bool) {
+	return md.stringTag(TagSha512)
+}
+
+// Exif returns the EXIF data of a stored image keyed by EXIF tag names. If the
+// file has no EXIF data or the information is unavailable, the second value
+// (ok) will be set to false.
+func (md Metadata) Exif() (exif map[string]interface{}, ok bool) {
+	exif, ok = md[string(TagExif)].(map[string]interface{})
+	return
+}
+
+// Colorspace returns the colour space of a stored image, like "srgb". The
+// second value (ok) is set to false if the information is unavailable.
+func (md Metadata) Colorspace() (colorspace string, ok bool) {
+	return md.stringTag(TagColorspace)
+}
+
+// Pages returns the number of pages of a stored document. The second value
+// (ok) is set to false if the information is unavailable.
+func (md Metadata) Pages() (pages uint64, ok bool) {
+	if val, found := md[string(TagPages)]; found && val != nil {
+		ok = decodeUint(&pages, TagPages, val) == nil
+	}
+	return
+}
+
+// Duration returns the playing time of a stored audio or video file. The
+// second value (ok) is set to false if the information is unavailable.
+func (md Metadata) Duration() (duration time.Duration, ok bool) {
+	if val, found := md[string(TagDuration)]; found && val != nil {
+		ok = decodeDuration(&duration, TagDuration, val) == nil
+	}
+	return
+}
+
+// stringTag returns the value of the tag if it is a string. Unlike the older
+// accessors, it does not panic on values of other types.
+func (md Metadata) stringTag(tag MetaTag) (s string, ok bool) {
+	s, ok = md[string(tag)].(string)
+	return
+}
+
 // Stat allows the user to get more detailed metadata about the stored file.
 func (c *Client) Stat(src *Blob, opt *StatOpts) (Metadata, error) {
+	return c.StatContext(context.Background(), src, opt)
+}
+
+// StatContext works like Stat but binds the request to the provided context.
+func (c *Client) StatContext(ctx context.Context, src *Blob, opt *StatOpts) (Metadata, error) {
 	blobURL, err := url.Parse(src.URL)
 	if err != nil {
 		return nil, err
@@ -160,8 +258,11 @@ func (c *Client) Stat(src *Blob, opt *StatOpts) (Metadata, error) {
 	if opt != nil {
 		blobURL.RawQuery = opt.toValues().Encode()
 	}
+	if err := c.signURL(blobURL, blobPolicy(MetStat, src)); err != nil {
+		return nil, err
+	}
 	blobURL.Path = path.Join(blobURL.Path, "metadata")
-	resp, err := c.do("GET", blobURL.String(), "", nil)
+	resp, err := c.do(withCall(ctx, &Call{Method: MetStat, Handle: src.Handle(), Opts: opt}), "GET", blobURL.String(), "", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -171,7 +272,7 @@ func (c *Client) Stat(src *Blob, opt *StatOpts) (Metadata, error) {
 	}
 	md := make(Metadata)
 	if err := json.NewDecoder(resp.Body).Decode(&md); err != nil {
-		return nil, err
+		return nil, failCall(resp, err)
 	}
 	return md, nil
 }
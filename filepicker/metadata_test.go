@@ -220,6 +220,84 @@ func TestStatMetadata(t *testing.T) {
 			Value: "",
 			Ok:    false,
 		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagSha224: "abc"},
+			Call:  filepicker.Metadata.Sha224Hash,
+			Value: "abc",
+			Ok:    true,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagSha256: "abc"},
+			Call:  filepicker.Metadata.Sha256Hash,
+			Value: "abc",
+			Ok:    true,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagSha384: 7},
+			Call:  filepicker.Metadata.Sha384Hash,
+			Value: "",
+			Ok:    false,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagSha512: "abc"},
+			Call:  filepicker.Metadata.Sha512Hash,
+			Value: "abc",
+			Ok:    true,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagExif: map[string]interface{}{"Make": "Canon"}},
+			Call:  filepicker.Metadata.Exif,
+			Value: map[string]interface{}{"Make": "Canon"},
+			Ok:    true,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagExif: "Canon"},
+			Call:  filepicker.Metadata.Exif,
+			Value: map[string]interface{}(nil),
+			Ok:    false,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagColorspace: "sRGB"},
+			Call:  filepicker.Metadata.Colorspace,
+			Value: "sRGB",
+			Ok:    true,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagPages: 12},
+			Call:  filepicker.Metadata.Pages,
+			Value: uint64(12),
+			Ok:    true,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagPages: -1},
+			Call:  filepicker.Metadata.Pages,
+			Value: uint64(0),
+			Ok:    false,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagPages: 18446744073709551616.0},
+			Call:  filepicker.Metadata.Pages,
+			Value: uint64(0),
+			Ok:    false,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagDuration: 2.5},
+			Call:  filepicker.Metadata.Duration,
+			Value: 2500 * time.Millisecond,
+			Ok:    true,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagDuration: "long"},
+			Call:  filepicker.Metadata.Duration,
+			Value: time.Duration(0),
+			Ok:    false,
+		},
+		{
+			Res:   map[filepicker.MetaTag]interface{}{filepicker.TagDuration: 1e10},
+			Call:  filepicker.Metadata.Duration,
+			Value: time.Duration(0),
+			Ok:    false,
+		},
 	}
 
 	for i, test := range tests {
@@ -260,3 +338,26 @@ func TestStatError(t *testing.T) {
 		t.Errorf("want error message == %q; got %q", fperr, err)
 	}
 }
+
+func TestAllTags(t *testing.T) {
+	hashes := filepicker.HashTags()
+	all := filepicker.AllTags()
+	if len(hashes) != 5 || hashes[0] != filepicker.TagMd5Hash {
+		t.Errorf("want md5 and four sha hashes; got %v", hashes)
+	}
+	seen := make(map[filepicker.MetaTag]bool)
+	for _, tag := range all {
+		if seen[tag] {
+			t.Errorf("want tag %q listed once", tag)
+		}
+		seen[tag] = true
+	}
+	for _, tag := range append(hashes, filepicker.TagExif, filepicker.TagPages, filepicker.TagDuration) {
+		if !seen[tag] {
+			t.Errorf("want tag %q in AllTags", tag)
+		}
+	}
+	if opt := filepicker.StatAll(); !reflect.DeepEqual(opt.Tags, all) {
+		t.Errorf("want StatAll tags == %v; got %v", all, opt.Tags)
+	}
+}
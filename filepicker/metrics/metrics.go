@@ -0,0 +1,226 @@
+// Package metrics provides an instrumentation of filepicker clients which
+// collects call counters and latency histograms and exposes them in the text
+// format understood by Prometheus.
+//
+// A Collector is installed as the instrumentation of a client and served on
+// the metrics endpoint of the application:
+//
+//	collector := metrics.NewCollector(nil)
+//	client.Instrumentation = collector
+//	http.Handle("/metrics", collector)
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency histogram
+// buckets used when NewCollector is given none.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector is a filepicker.Instrumentation which collects the following
+// metrics, labeled with the method of the call as "op":
+//
+//	filepicker_calls_total                counter, also labeled with the
+//	                                      status "code" and the error "class"
+//	filepicker_calls_in_flight            gauge
+//	filepicker_call_duration_seconds      histogram
+//	filepicker_sent_bytes_total           counter
+//	filepicker_received_bytes_total       counter
+//
+// Collector is safe for concurrent use.
+type Collector struct {
+	mu        sync.Mutex
+	buckets   []float64
+	calls     map[callLabels]uint64
+	inFlight  map[string]int64
+	durations map[string]*histogram
+	sent      map[string]uint64
+	received  map[string]uint64
+}
+
+// callLabels are the labels of filepicker_calls_total counter.
+type callLabels struct {
+	op, code, class string
+}
+
+// histogram holds the observations of a single latency histogram.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewCollector creates a collector whose latency histograms use the given
+// bucket upper bounds, in seconds. If buckets is empty, DefaultBuckets are used.
+func NewCollector(buckets []float64) *Collector {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Collector{
+		buckets:   buckets,
+		calls:     make(map[callLabels]uint64),
+		inFlight:  make(map[string]int64),
+		durations: make(map[string]*histogram),
+		sent:      make(map[string]uint64),
+		received:  make(map[string]uint64),
+	}
+}
+
+// Start implements filepicker.Instrumentation interface.
+func (c *Collector) Start(ctx context.Context, ev *filepicker.StartEvent) context.Context {
+	op := string(ev.Call.Method)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight[op]++
+	if ev.Size > 0 {
+		c.sent[op] += uint64(ev.Size)
+	}
+	return ctx
+}
+
+// End implements filepicker.Instrumentation interface.
+func (c *Collector) End(ctx context.Context, ev *filepicker.EndEvent) {
+	op, code := string(ev.Call.Method), ""
+	if ev.Status != 0 {
+		code = strconv.Itoa(ev.Status)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight[op]--
+	c.calls[callLabels{op, code, string(ev.Class)}]++
+	if ev.Size > 0 {
+		c.received[op] += uint64(ev.Size)
+	}
+	h := c.durations[op]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.durations[op] = h
+	}
+	seconds := ev.Duration.Seconds()
+	for i, bound := range c.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// WriteTo writes the metrics to w in Prometheus text format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	c.mu.Lock()
+	c.writeCalls(&b)
+	writeGauge(&b, "filepicker_calls_in_flight", "Number of filepicker calls in progress.", c.inFlight)
+	c.writeDurations(&b)
+	writeCounter(&b, "filepicker_sent_bytes_total", "Number of bytes sent in filepicker request bodies.", c.sent)
+	writeCounter(&b, "filepicker_received_bytes_total", "Number of bytes announced in filepicker response bodies.", c.received)
+	c.mu.Unlock()
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP implements http.Handler interface. It responds with the metrics
+// in Prometheus text format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+func (c *Collector) writeCalls(b *strings.Builder) {
+	writeHeader(b, "filepicker_calls_total", "Number of completed filepicker calls.", "counter")
+	keys := make([]callLabels, 0, len(c.calls))
+	for key := range c.calls {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.op != b.op {
+			return a.op < b.op
+		}
+		if a.code != b.code {
+			return a.code < b.code
+		}
+		return a.class < b.class
+	})
+	for _, key := range keys {
+		fmt.Fprintf(b, "filepicker_calls_total{op=%s,code=%s,class=%s} %d\n",
+			quote(key.op), quote(key.code), quote(key.class), c.calls[key])
+	}
+}
+
+func (c *Collector) writeDurations(b *strings.Builder) {
+	const name = "filepicker_call_duration_seconds"
+	writeHeader(b, name, "Latency of filepicker calls until response headers are received.", "histogram")
+	for _, op := range sortedKeys(c.durations) {
+		h := c.durations[op]
+		for i, bound := range c.buckets {
+			fmt.Fprintf(b, "%s_bucket{op=%s,le=%s} %d\n", name, quote(op), quote(formatFloat(bound)), h.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{op=%s,le=\"+Inf\"} %d\n", name, quote(op), h.count)
+		fmt.Fprintf(b, "%s_sum{op=%s} %s\n", name, quote(op), formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count{op=%s} %d\n", name, quote(op), h.count)
+	}
+}
+
+func writeCounter(b *strings.Builder, name, help string, values map[string]uint64) {
+	writeHeader(b, name, help, "counter")
+	for _, op := range sortedKeys(values) {
+		fmt.Fprintf(b, "%s{op=%s} %d\n", name, quote(op), values[op])
+	}
+}
+
+func writeGauge(b *strings.Builder, name, help string, values map[string]int64) {
+	writeHeader(b, name, help, "gauge")
+	for _, op := range sortedKeys(values) {
+		fmt.Fprintf(b, "%s{op=%s} %d\n", name, quote(op), values[op])
+	}
+}
+
+func writeHeader(b *strings.Builder, name, help, kind string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sortedKeys returns the keys of a map indexed by operation names in order.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]uint64:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]int64:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*histogram:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelEscaper escapes label values as required by Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quote(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,81 @@
+package metrics_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/filepicker/filepicker-go/filepicker/filepickertest"
+	"github.com/filepicker/filepicker-go/filepicker/metrics"
+)
+
+const FakeApiKey = "0KKK1"
+
+func TestCollector(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	client := srv.Client()
+	collector := metrics.NewCollector([]float64{10, 1})
+	client.Instrumentation = collector
+
+	blob, err := client.StoreReader("a.txt", strings.NewReader("ABC"), nil)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	client.Stat(blob, nil)
+	client.Stat(blob, nil)
+	client.Stat(srv.Blob("XXXXXXXXXXXXXXXXXXXX"), nil)
+	var buff bytes.Buffer
+	client.DownloadTo(blob, nil, &buff)
+
+	metricsSrv := httptest.NewServer(collector)
+	defer metricsSrv.Close()
+	resp, err := http.Get(metricsSrv.URL)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("want Prometheus content type; got %q", ct)
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	out := string(data)
+
+	for i, line := range []string{
+		"# TYPE filepicker_calls_total counter",
+		`filepicker_calls_total{op="stat",code="200",class=""} 2`,
+		`filepicker_calls_total{op="stat",code="404",class="not_found"} 1`,
+		`filepicker_calls_total{op="store",code="200",class=""} 1`,
+		`filepicker_calls_in_flight{op="stat"} 0`,
+		"# TYPE filepicker_call_duration_seconds histogram",
+		`filepicker_call_duration_seconds_bucket{op="stat",le="1"} 3`,
+		`filepicker_call_duration_seconds_bucket{op="stat",le="10"} 3`,
+		`filepicker_call_duration_seconds_bucket{op="stat",le="+Inf"} 3`,
+		`filepicker_call_duration_seconds_count{op="stat"} 3`,
+		`filepicker_received_bytes_total{op="read"} 3`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("want output containing %q (i:%d)", line, i)
+		}
+	}
+	if !strings.Contains(out, `filepicker_sent_bytes_total{op="store"} `) {
+		t.Errorf("want sent bytes of store calls; got\n%s", out)
+	}
+	if strings.Index(out, `le="1"`) > strings.Index(out, `le="10"`) {
+		t.Errorf("want buckets in ascending order; got\n%s", out)
+	}
+}
+
+func TestCollectorEmpty(t *testing.T) {
+	var buff bytes.Buffer
+	n, err := metrics.NewCollector(nil).WriteTo(&buff)
+	if err != nil || n != int64(buff.Len()) {
+		t.Errorf("want n == %d, err == nil; got %d, %v", buff.Len(), n, err)
+	}
+	if !strings.Contains(buff.String(), "# TYPE filepicker_calls_total counter\n") {
+		t.Errorf("want metric headers; got\n%s", buff.String())
+	}
+}
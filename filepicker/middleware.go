@@ -0,0 +1,63 @@
+package filepicker
+
+import (
+	"context"
+	"net/http"
+)
+
+// Call describes a logical call made by a Client, like a single Stat or
+// StoreReader invocation.
+type Call struct {
+	// Method is the kind of the call.
+	Method Method
+
+	// Handle identifies the file the call operates on. It is empty for calls
+	// that create new files, like store and pick.
+	Handle string
+
+	// Opts is the options pointer passed to the Client method, like *StatOpts
+	// for Stat calls. It may hold a nil pointer.
+	Opts interface{}
+}
+
+// RoundTripFunc sends the HTTP request of a call and returns the response.
+type RoundTripFunc func(call *Call, req *http.Request) (*http.Response, error)
+
+// Middleware intercepts the calls made by a Client. It returns a RoundTripFunc
+// which may inspect or modify the request, call next or answer the request on
+// its own, and inspect or replace the response. This makes it possible to add
+// authentication headers, tracing, logging, metrics or caching.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// callKey is the context key under which the description of a call is stored.
+type callKey struct{}
+
+// withCall returns a copy of ctx that carries the description of the call.
+func withCall(ctx context.Context, call *Call) context.Context {
+	return context.WithValue(ctx, callKey{}, call)
+}
+
+// callOf returns the description of the call made with ctx.
+func callOf(ctx context.Context) *Call {
+	if call, ok := ctx.Value(callKey{}).(*Call); ok {
+		return call
+	}
+	return &Call{}
+}
+
+// send passes the request through the client's middleware chain. The chain
+// ends with roundTrip, so retries and rate limits are applied within it. The
+// whole chain is observed by the client's instrumentation.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	call := callOf(req.Context())
+	req, end := c.instrument(call, req)
+	next := func(_ *Call, req *http.Request) (*http.Response, error) {
+		return c.roundTrip(req)
+	}
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		next = c.Middleware[i](next)
+	}
+	resp, err := next(call, req)
+	end(resp, err)
+	return resp, err
+}
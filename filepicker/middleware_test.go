@@ -0,0 +1,179 @@
+package filepicker_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+func TestMiddlewareCall(t *testing.T) {
+	blob := filepicker.NewBlob(FakeHandle)
+	statOpts := &filepicker.StatOpts{}
+	storeOpts := &filepicker.StoreOpts{}
+	tests := []struct {
+		Do     func(*filepicker.Client)
+		Method filepicker.Method
+		Handle string
+		Opts   interface{}
+	}{
+		{
+			Do:     func(c *filepicker.Client) { c.Stat(blob, statOpts) },
+			Method: filepicker.MetStat,
+			Handle: FakeHandle,
+			Opts:   statOpts,
+		},
+		{
+			Do:     func(c *filepicker.Client) { c.StoreReader("a.txt", strings.NewReader("A"), storeOpts) },
+			Method: filepicker.MetStore,
+			Opts:   storeOpts,
+		},
+		{
+			Do:     func(c *filepicker.Client) { c.PickURL("http://example.com", nil) },
+			Method: filepicker.MetPick,
+			Opts:   (*filepicker.PickOpts)(nil),
+		},
+		{
+			Do:     func(c *filepicker.Client) { c.DownloadTo(blob, nil, &bytes.Buffer{}) },
+			Method: filepicker.MetRead,
+			Handle: FakeHandle,
+			Opts:   (*filepicker.DownloadOpts)(nil),
+		},
+		{
+			Do:     func(c *filepicker.Client) { c.WriteURL(blob, "http://example.com", nil) },
+			Method: filepicker.MetWriteurl,
+			Handle: FakeHandle,
+			Opts:   (*filepicker.WriteOpts)(nil),
+		},
+		{
+			Do:     func(c *filepicker.Client) { c.Remove(blob, nil) },
+			Method: filepicker.MetRemove,
+			Handle: FakeHandle,
+			Opts:   (*filepicker.RemoveOpts)(nil),
+		},
+	}
+
+	for i, test := range tests {
+		var calls []filepicker.Call
+		var auth string
+		handler := func(w http.ResponseWriter, req *http.Request) {
+			auth = req.Header.Get("Authorization")
+		}
+		client := filepicker.NewClient(FakeApiKey)
+		mock := MockServer(t, client, handler)
+		client.Middleware = []filepicker.Middleware{
+			func(next filepicker.RoundTripFunc) filepicker.RoundTripFunc {
+				return func(call *filepicker.Call, req *http.Request) (*http.Response, error) {
+					calls = append(calls, *call)
+					req.Header.Set("Authorization", "Bearer T")
+					return next(call, req)
+				}
+			},
+		}
+		test.Do(client)
+		mock.Close()
+		if len(calls) != 1 {
+			t.Errorf("want 1 call; got %d (i:%d)", len(calls), i)
+			continue
+		}
+		if call := calls[0]; call.Method != test.Method || call.Handle != test.Handle || call.Opts != test.Opts {
+			t.Errorf("want call == {%s %s %v}; got %+v (i:%d)", test.Method, test.Handle, test.Opts, call, i)
+		}
+		if auth != "Bearer T" {
+			t.Errorf("want Authorization == %q; got %q (i:%d)", "Bearer T", auth, i)
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var order []string
+	trace := func(name string) filepicker.Middleware {
+		return func(next filepicker.RoundTripFunc) filepicker.RoundTripFunc {
+			return func(call *filepicker.Call, req *http.Request) (*http.Response, error) {
+				order = append(order, name+">")
+				resp, err := next(call, req)
+				order = append(order, "<"+name)
+				return resp, err
+			}
+		}
+	}
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		order = append(order, "server")
+		w.Write([]byte("success"))
+	}
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+	client.Middleware = []filepicker.Middleware{trace("a"), trace("b")}
+
+	if err := client.Remove(filepicker.NewBlob(FakeHandle), nil); err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	if want := "a> b> server <b <a"; strings.Join(order, " ") != want {
+		t.Errorf("want order == %q; got %q", want, strings.Join(order, " "))
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		t.Error("want no request; got one")
+	}
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+	client.Middleware = []filepicker.Middleware{
+		func(next filepicker.RoundTripFunc) filepicker.RoundTripFunc {
+			return func(call *filepicker.Call, req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{},
+					Body:       ioutil.NopCloser(strings.NewReader(`{"size":7}`)),
+					Request:    req,
+				}, nil
+			}
+		},
+	}
+
+	md, err := client.Stat(filepicker.NewBlob(FakeHandle), nil)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	if size, _ := md.Size(); size != 7 {
+		t.Errorf("want size == 7; got %d", size)
+	}
+}
+
+func TestMiddlewareRetries(t *testing.T) {
+	attempts := 0
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if attempts++; attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("success"))
+	}
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+	client.Retry = &filepicker.RetryPolicy{MinBackoff: time.Millisecond}
+	calls := 0
+	client.Middleware = []filepicker.Middleware{
+		func(next filepicker.RoundTripFunc) filepicker.RoundTripFunc {
+			return func(call *filepicker.Call, req *http.Request) (*http.Response, error) {
+				calls++
+				return next(call, req)
+			}
+		},
+	}
+
+	if err := client.Remove(filepicker.NewBlob(FakeHandle), nil); err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	if attempts != 2 || calls != 1 {
+		t.Errorf("want 2 attempts within 1 call; got %d, %d", attempts, calls)
+	}
+}
@@ -0,0 +1,187 @@
+package filepicker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// readAhead is the minimal number of bytes fetched by a single request made by
+// BlobReader.
+const readAhead = 64 << 10
+
+// BlobReader is a read-only, seekable view of a stored file. The data is
+// fetched on demand with byte range requests. Small reads are served from a
+// read-ahead buffer, so reading the file sequentially does not issue a request
+// per call. BlobReader implements io.ReadSeekCloser and io.ReaderAt interfaces
+// and is safe for concurrent use.
+type BlobReader struct {
+	c    *Client
+	ctx  context.Context
+	src  *Blob
+	opt  DownloadOpts
+	size int64
+
+	mu     sync.Mutex
+	offset int64  // Position used by Read and Seek methods.
+	buf    []byte // Read-ahead buffer.
+	bufOff int64  // Position of the first byte of the buffer.
+	closed bool
+}
+
+// errReaderClosed is returned when a closed BlobReader is used.
+var errReaderClosed = errors.New("filepicker: read from closed blob reader")
+
+// Open returns a reader of the stored file. Download options, if provided, are
+// used for each request that fetches the data, except for Ranges and Resume
+// fields which are ignored.
+func (c *Client) Open(src *Blob, opt *DownloadOpts) (*BlobReader, error) {
+	return c.OpenContext(context.Background(), src, opt)
+}
+
+// OpenContext works like Open but binds all requests made by the reader to the
+// provided context.
+func (c *Client) OpenContext(ctx context.Context, src *Blob, opt *DownloadOpts) (*BlobReader, error) {
+	br := &BlobReader{c: c, ctx: ctx, src: src}
+	if opt != nil {
+		br.opt = *opt
+	}
+	br.opt.Resume = false
+	if err := br.init(); err != nil {
+		return nil, err
+	}
+	return br, nil
+}
+
+// init fetches the first chunk of the file and reads the file size.
+func (br *BlobReader) init() error {
+	br.opt.Ranges = []ByteRange{{Offset: 0, Length: readAhead}}
+	resp, err := br.c.download(br.ctx, br.src, &br.opt)
+	if rerr, ok := err.(RangeError); ok && rerr.Size == 0 {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if br.size = contentSize(resp); br.size < 0 {
+		return fmt.Errorf("filepicker: unknown file size (handle %q)", br.src.Handle())
+	}
+	br.buf = make([]byte, readAhead)
+	n, err := io.ReadFull(downloadBody(resp, &br.opt), br.buf)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = nil
+	}
+	br.buf = br.buf[:n]
+	return err
+}
+
+// Size returns the size of the file in bytes.
+func (br *BlobReader) Size() int64 {
+	return br.size
+}
+
+// Read satisfies io.Reader interface.
+func (br *BlobReader) Read(p []byte) (int, error) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	n, err := br.readAt(p, br.offset)
+	br.offset += int64(n)
+	return n, err
+}
+
+// ReadAt satisfies io.ReaderAt interface.
+func (br *BlobReader) ReadAt(p []byte, off int64) (int, error) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	return br.readAt(p, off)
+}
+
+// Seek satisfies io.Seeker interface.
+func (br *BlobReader) Seek(offset int64, whence int) (int64, error) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += br.offset
+	case io.SeekEnd:
+		offset += br.size
+	default:
+		return 0, errors.New("filepicker: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("filepicker: negative position")
+	}
+	br.offset = offset
+	return offset, nil
+}
+
+// Close satisfies io.Closer interface. It releases the read-ahead buffer.
+func (br *BlobReader) Close() error {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.closed, br.buf = true, nil
+	return nil
+}
+
+// readAt reads len(p) bytes starting at the given offset. It must be called
+// with the mutex held.
+func (br *BlobReader) readAt(p []byte, off int64) (n int, err error) {
+	switch {
+	case br.closed:
+		return 0, errReaderClosed
+	case off < 0:
+		return 0, errors.New("filepicker: negative offset")
+	case off >= br.size:
+		return 0, io.EOF
+	}
+	if left := br.size - off; int64(len(p)) > left {
+		p, err = p[:left], io.EOF
+	}
+	for n < len(p) {
+		k, ferr := br.readChunk(p[n:], off+int64(n))
+		if n += k; ferr != nil {
+			return n, ferr
+		}
+	}
+	return n, err
+}
+
+// readChunk reads the data at the given offset from the read-ahead buffer. If
+// the buffer does not contain that data, it is fetched from the service. Large
+// reads bypass the buffer.
+func (br *BlobReader) readChunk(p []byte, off int64) (int, error) {
+	if off >= br.bufOff && off < br.bufOff+int64(len(br.buf)) {
+		return copy(p, br.buf[off-br.bufOff:]), nil
+	}
+	if len(p) >= readAhead {
+		return br.fetch(p, off)
+	}
+	length := int64(readAhead)
+	if left := br.size - off; left < length {
+		length = left
+	}
+	if cap(br.buf) < int(length) {
+		br.buf = make([]byte, length)
+	}
+	n, err := br.fetch(br.buf[:length], off)
+	br.buf, br.bufOff = br.buf[:n], off
+	if err != nil {
+		return 0, err
+	}
+	return copy(p, br.buf), nil
+}
+
+// fetch downloads len(p) bytes of the file starting at the given offset.
+func (br *BlobReader) fetch(p []byte, off int64) (int, error) {
+	rc, err := br.c.DownloadRangeContext(br.ctx, br.src, &br.opt,
+		ByteRange{Offset: off, Length: int64(len(p))})
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	return io.ReadFull(rc, p)
+}
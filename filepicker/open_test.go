@@ -0,0 +1,172 @@
+package filepicker_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+var _ interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+} = (*filepicker.BlobReader)(nil)
+
+func contentHandler(content []byte, calls *int) http.HandlerFunc {
+	var mu sync.Mutex
+	return func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		*calls++
+		mu.Unlock()
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(content))
+	}
+}
+
+// zipArchive creates a zip archive of files whose content repeats their names.
+func zipArchive(t *testing.T, names ...string) *bytes.Buffer {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("want err == nil; got %v", err)
+		}
+		w.Write([]byte(strings.Repeat(name, 1000)))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	return &archive
+}
+
+func TestOpenZip(t *testing.T) {
+	archive := zipArchive(t, "a.txt", "b.txt")
+
+	var calls int
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, contentHandler(archive.Bytes(), &calls))
+	defer mock.Close()
+
+	br, err := client.Open(blob, nil)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer br.Close()
+	if size := int64(archive.Len()); br.Size() != size {
+		t.Errorf("want br.Size() == %d; got %d", size, br.Size())
+	}
+	zr, err := zip.NewReader(br, br.Size())
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	for _, file := range zr.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("want err == nil; got %v", err)
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if want := strings.Repeat(file.Name, 1000); err != nil || string(data) != want {
+			t.Errorf("want content of %s; got %q (err: %v)", file.Name, data, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("want calls == 1; got %d", calls)
+	}
+}
+
+func TestOpenReadSeek(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 20000)
+
+	var calls int
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, contentHandler(content, &calls))
+	defer mock.Close()
+
+	br, err := client.Open(blob, nil)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	data, err := ioutil.ReadAll(br)
+	if err != nil || !bytes.Equal(data, content) {
+		t.Errorf("want all content; got %d bytes (err: %v)", len(data), err)
+	}
+	if pos, err := br.Seek(-5, io.SeekEnd); err != nil || pos != int64(len(content)-5) {
+		t.Errorf("want pos == %d; got %d (err: %v)", len(content)-5, pos, err)
+	}
+	if data, _ := ioutil.ReadAll(br); string(data) != "56789" {
+		t.Errorf("want data == %q; got %q", "56789", data)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go readAt(t, &wg, br, int64(i)*20000+3, "3456789012")
+	}
+	wg.Wait()
+
+	if err := br.Close(); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if _, err := br.Read(make([]byte, 1)); err == nil {
+		t.Error("want err != nil; got nil")
+	}
+}
+
+// readAt reads from br at the given offset and compares the data with want.
+func readAt(t *testing.T, wg *sync.WaitGroup, br *filepicker.BlobReader, off int64, want string) {
+	defer wg.Done()
+	p := make([]byte, len(want))
+	if n, err := br.ReadAt(p, off); err != nil || string(p[:n]) != want {
+		t.Errorf("want p == %q; got %q (err: %v)", want, p[:n], err)
+	}
+}
+
+func TestOpenServeContent(t *testing.T) {
+	var calls int
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, contentHandler([]byte(downloadFileContent), &calls))
+	defer mock.Close()
+
+	br, err := client.Open(blob, nil)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer br.Close()
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Range", "bytes=4-7")
+	rec := httptest.NewRecorder()
+	http.ServeContent(rec, req, "", time.Time{}, br)
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "LOAD" {
+		t.Errorf("want 206 LOAD; got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestOpenEmpty(t *testing.T) {
+	var calls int
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, contentHandler(nil, &calls))
+	defer mock.Close()
+
+	br, err := client.Open(blob, nil)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer br.Close()
+	if n, err := br.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Errorf("want n == 0 and err == io.EOF; got %d and %v", n, err)
+	}
+}
@@ -1,8 +1,8 @@
 package filepicker
 
 import (
+	"context"
 	"net/url"
-	"path"
 )
 
 // PickOpts structure allows the user to configure security options when picking a file.
@@ -23,21 +23,29 @@ func (po *PickOpts) toValues() url.Values {
 // the file from its storage, the blob object returned from this call will be
 // invalid.
 func (c *Client) PickURL(dataURL string, opt *PickOpts) (*Blob, error) {
-	return c.storeURL(dataURL, func() string {
-		return c.toPickURL(opt).String()
-	})
+	return c.PickURLContext(context.Background(), dataURL, opt)
 }
 
-func (c *Client) toPickURL(opt *PickOpts) *url.URL {
+// PickURLContext works like PickURL but binds the request to the provided
+// context.
+func (c *Client) PickURLContext(ctx context.Context, dataURL string, opt *PickOpts) (*Blob, error) {
+	pickURL, err := c.toPickURL(opt)
+	if err != nil {
+		return nil, err
+	}
+	return c.storeURL(withCall(ctx, &Call{Method: MetPick, Opts: opt}), dataURL, pickURL)
+}
+
+func (c *Client) toPickURL(opt *PickOpts) (*url.URL, error) {
 	values := url.Values{}
 	if opt != nil {
 		values = opt.toValues()
 	}
 	values.Set("key", c.apiKey)
-	return &url.URL{
-		Scheme:   apiURL.Scheme,
-		Host:     apiURL.Host,
-		Path:     path.Join("api", "pick"),
-		RawQuery: values.Encode(),
+	if err := c.sign(values, &PolicyOpts{Call: []Method{MetPick}}); err != nil {
+		return nil, err
 	}
+	pickURL := endpoint(c.BaseURL, "api", "pick")
+	pickURL.RawQuery = values.Encode()
+	return pickURL, nil
 }
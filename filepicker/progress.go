@@ -0,0 +1,86 @@
+package filepicker
+
+import (
+	"io"
+	"time"
+)
+
+// Progress describes the state of a data transfer.
+type Progress struct {
+	// Bytes is the number of bytes transferred so far.
+	Bytes int64
+
+	// Total is the expected number of bytes to transfer or -1 if it is unknown.
+	Total int64
+
+	// Elapsed is the time that passed since the transfer started.
+	Elapsed time.Duration
+
+	// Rate is the recent throughput in bytes per second. It is computed over
+	// the last second of the transfer, or over the whole transfer if it is
+	// shorter than that.
+	Rate float64
+}
+
+// ProgressFunc is a callback invoked each time a chunk of data is transferred.
+// It is called from the goroutine that performs the transfer, so it should
+// return quickly. The callback is not invoked while the transfer is stalled,
+// thus the time since its last call can be used to detect stalls.
+type ProgressFunc func(Progress)
+
+// rateWindow is the period over which the throughput of a transfer is measured.
+const rateWindow = time.Second
+
+// progressReader reports the number of bytes read from the underlying reader
+// to a progress callback.
+type progressReader struct {
+	r     io.Reader
+	fn    ProgressFunc
+	start time.Time
+	state Progress
+
+	window      time.Time // Start of the current throughput window.
+	windowBytes int64     // Bytes read in the current throughput window.
+}
+
+// newProgressReader wraps r so that each read is reported to fn. If fn is nil,
+// the reader is returned unchanged.
+func newProgressReader(r io.Reader, total int64, fn ProgressFunc) io.Reader {
+	if fn == nil {
+		return r
+	}
+	now := time.Now()
+	return &progressReader{
+		r:      r,
+		fn:     fn,
+		start:  now,
+		state:  Progress{Total: total},
+		window: now,
+	}
+}
+
+// Read satisfies io.Reader interface.
+func (pr *progressReader) Read(p []byte) (n int, err error) {
+	n, err = pr.r.Read(p)
+	if n > 0 {
+		now := time.Now()
+		pr.state.Bytes += int64(n)
+		pr.state.Elapsed = now.Sub(pr.start)
+		pr.updateRate(now, int64(n))
+		pr.fn(pr.state)
+	}
+	return
+}
+
+// updateRate accounts n bytes read at the given time in the throughput value.
+func (pr *progressReader) updateRate(now time.Time, n int64) {
+	pr.windowBytes += n
+	span := now.Sub(pr.window)
+	switch {
+	case span >= rateWindow:
+		pr.state.Rate = float64(pr.windowBytes) / span.Seconds()
+		pr.window, pr.windowBytes = now, 0
+	case pr.window.Equal(pr.start) && span > 0:
+		pr.state.Rate = float64(pr.windowBytes) / span.Seconds()
+	}
+}
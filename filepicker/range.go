@@ -0,0 +1,174 @@
+package filepicker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ByteRange specifies a contiguous range of bytes of the stored file.
+type ByteRange struct {
+	// Offset is the position of the first byte in the range. A negative value
+	// selects the last -Offset bytes of the file, in which case Length is
+	// ignored.
+	Offset int64
+
+	// Length is the number of bytes in the range. Zero selects all bytes from
+	// Offset to the end of the file.
+	Length int64
+}
+
+// String returns the representation of the range used in Range HTTP header.
+func (br ByteRange) String() string {
+	switch {
+	case br.Offset < 0:
+		return strconv.FormatInt(br.Offset, 10)
+	case br.Length <= 0:
+		return strconv.FormatInt(br.Offset, 10) + "-"
+	}
+	return fmt.Sprintf("%d-%d", br.Offset, br.Offset+br.Length-1)
+}
+
+// rangeHeader creates the value of Range HTTP header from the given ranges.
+func rangeHeader(ranges []ByteRange) string {
+	specs := make([]string, len(ranges))
+	for i, br := range ranges {
+		specs[i] = br.String()
+	}
+	return "bytes=" + strings.Join(specs, ",")
+}
+
+// RangeError is returned when none of the requested byte ranges overlaps the
+// stored file.
+type RangeError struct {
+	// Size is the size of the stored file or -1 if it is unknown.
+	Size int64
+}
+
+// Error satisfies builtin.error interface.
+func (e RangeError) Error() string {
+	if e.Size < 0 {
+		return "filepicker: requested range not satisfiable"
+	}
+	return fmt.Sprintf("filepicker: requested range not satisfiable (size %d)", e.Size)
+}
+
+// errRangeIgnored is returned when the service sends the whole file in response
+// to a multiple ranges request.
+var errRangeIgnored = errors.New("filepicker: byte ranges were not honored by the service")
+
+// newRangeError creates a RangeError from Content-Range header of the response.
+// The header has "bytes */size" format.
+func newRangeError(resp *http.Response) error {
+	return RangeError{Size: contentSize(resp)}
+}
+
+// contentSize returns the size of the whole file which content was sent in the
+// response. The size of partial content is read from Content-Range header that
+// has "bytes first-last/size" or "bytes */size" format. It returns -1 if the
+// size is unknown.
+func contentSize(resp *http.Response) int64 {
+	if resp.StatusCode == http.StatusOK {
+		return resp.ContentLength
+	}
+	contentRange := resp.Header.Get("Content-Range")
+	if i := strings.LastIndex(contentRange, "/"); i >= 0 {
+		if n, err := strconv.ParseInt(contentRange[i+1:], 10, 64); err == nil {
+			return n
+		}
+	}
+	return -1
+}
+
+// rangeBody replaces the body of a successful response with a reader that
+// produces the content of requested ranges one after another.
+func rangeBody(resp *http.Response, ranges []ByteRange) error {
+	if len(ranges) == 0 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusOK {
+		return emulateRange(resp, ranges)
+	}
+	mediatype, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediatype != "multipart/byteranges" {
+		return nil
+	}
+	resp.Body = &multipartRangeReader{
+		mr:   multipart.NewReader(resp.Body, params["boundary"]),
+		body: resp.Body,
+	}
+	return nil
+}
+
+// emulateRange cuts a single range out of the full response body. It is used
+// when the service does not support range requests.
+func emulateRange(resp *http.Response, ranges []ByteRange) error {
+	if len(ranges) != 1 {
+		return errRangeIgnored
+	}
+	offset, length := ranges[0].Offset, ranges[0].Length
+	if offset < 0 {
+		if resp.ContentLength < 0 {
+			return errRangeIgnored
+		}
+		if offset, length = resp.ContentLength+offset, -offset; offset < 0 {
+			offset = 0
+		}
+	}
+	if _, err := io.CopyN(ioutil.Discard, resp.Body, offset); err != nil {
+		if err == io.EOF {
+			return RangeError{Size: resp.ContentLength}
+		}
+		return err
+	}
+	reader := io.Reader(resp.Body)
+	if length > 0 {
+		reader = io.LimitReader(resp.Body, length)
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{reader, resp.Body}
+	return nil
+}
+
+// multipartRangeReader reads the content of all parts of multipart/byteranges
+// response body one after another.
+type multipartRangeReader struct {
+	mr   *multipart.Reader
+	part *multipart.Part
+	body io.Closer
+}
+
+// Read satisfies io.Reader interface.
+func (r *multipartRangeReader) Read(p []byte) (int, error) {
+	for {
+		if r.part == nil {
+			part, err := r.mr.NextPart()
+			if err != nil {
+				return 0, err
+			}
+			r.part = part
+		}
+		n, err := r.part.Read(p)
+		if err == io.EOF {
+			r.part = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+// Close satisfies io.Closer interface.
+func (r *multipartRangeReader) Close() error {
+	return r.body.Close()
+}
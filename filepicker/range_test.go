@@ -0,0 +1,128 @@
+package filepicker_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+func TestDownloadRange(t *testing.T) {
+	tests := []struct {
+		Ranges  []filepicker.ByteRange
+		Header  string
+		Content string
+	}{
+		{
+			Ranges:  nil,
+			Header:  "",
+			Content: "DOWNLOADTEST",
+		},
+		{
+			Ranges:  []filepicker.ByteRange{{Offset: 0, Length: 4}},
+			Header:  "bytes=0-3",
+			Content: "DOWN",
+		},
+		{
+			Ranges:  []filepicker.ByteRange{{Offset: 8}},
+			Header:  "bytes=8-",
+			Content: "TEST",
+		},
+		{
+			Ranges:  []filepicker.ByteRange{{Offset: -4}},
+			Header:  "bytes=-4",
+			Content: "TEST",
+		},
+		{
+			Ranges:  []filepicker.ByteRange{{Offset: 0, Length: 4}, {Offset: 8, Length: 4}},
+			Header:  "bytes=0-3,8-11",
+			Content: "DOWNTEST",
+		},
+	}
+
+	var reqRange string
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		reqRange = req.Header.Get("Range")
+		http.ServeContent(w, req, "", time.Time{}, strings.NewReader(downloadFileContent))
+	}
+
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	for i, test := range tests {
+		rc, err := client.DownloadRange(blob, nil, test.Ranges...)
+		if err != nil {
+			t.Fatalf("want err == nil; got %v (i:%d)", err, i)
+		}
+		content, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Errorf("want err == nil; got %v (i:%d)", err, i)
+		}
+		rc.Close()
+		if reqRange != test.Header {
+			t.Errorf("want reqRange == %q; got %q (i:%d)", test.Header, reqRange, i)
+		}
+		if string(content) != test.Content {
+			t.Errorf("want content == %q; got %q (i:%d)", test.Content, content, i)
+		}
+
+		var buff bytes.Buffer
+		opt := &filepicker.DownloadOpts{Ranges: test.Ranges}
+		if _, err := client.DownloadTo(blob, opt, &buff); err != nil {
+			t.Errorf("want err == nil; got %v (i:%d)", err, i)
+		}
+		if buff.String() != test.Content {
+			t.Errorf("want content == %q; got %q (i:%d)", test.Content, buff.String(), i)
+		}
+	}
+}
+
+func TestDownloadRangeNotSatisfiable(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		http.ServeContent(w, req, "", time.Time{}, strings.NewReader(downloadFileContent))
+	}
+
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	want := filepicker.RangeError{Size: int64(len(downloadFileContent))}
+	switch rc, err := client.DownloadRange(blob, nil, filepicker.ByteRange{Offset: 100}); {
+	case rc != nil:
+		t.Errorf("want rc == nil; got %v", rc)
+	case err != want:
+		t.Errorf("want err == %v; got %v", want, err)
+	}
+}
+
+func TestDownloadRangeIgnored(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(downloadFileContent))
+	}
+
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	rc, err := client.DownloadRange(blob, nil, filepicker.ByteRange{Offset: 4, Length: 4})
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer rc.Close()
+	if content, _ := ioutil.ReadAll(rc); string(content) != "LOAD" {
+		t.Errorf("want content == %q; got %q", "LOAD", content)
+	}
+
+	ranges := []filepicker.ByteRange{{Offset: 0, Length: 4}, {Offset: 8, Length: 4}}
+	if _, err := client.DownloadRange(blob, nil, ranges...); err == nil {
+		t.Error("want err != nil; got nil")
+	}
+}
@@ -0,0 +1,179 @@
+package filepicker
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// minRateFraction bounds how far a RateLimiter can slow down relative to
+	// its configured rate.
+	minRateFraction = 1.0 / 16
+
+	// recoveryStep is the fraction of the configured rate that a RateLimiter
+	// regains after each successful call.
+	recoveryStep = 1.0 / 16
+)
+
+// RateLimiter is a token bucket which limits the rate of calls made by a
+// Client. When the service responds with 429 Too Many Requests, the limiter
+// halves its rate and then gradually returns to the configured rate with each
+// successful call. It is safe for concurrent use and can be shared by several
+// methods or clients to enforce a common quota.
+type RateLimiter struct {
+	mu     sync.Mutex
+	limit  float64
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	stats  LimiterStats
+}
+
+// LimiterStats describes the waits imposed by a RateLimiter.
+type LimiterStats struct {
+	// Calls is the number of calls that passed the limiter.
+	Calls int64
+
+	// Delayed is the number of calls that had to wait.
+	Delayed int64
+
+	// Waited is the total time spent waiting.
+	Waited time.Duration
+
+	// MaxWait is the longest wait of a single call.
+	MaxWait time.Duration
+}
+
+// NewRateLimiter creates a limiter which allows rate calls per second on
+// average and bursts of up to burst calls. The rate must be positive.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if !(rate > 0) {
+		panic("filepicker: rate limit must be positive")
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		limit:  rate,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a call can be made or the context is done. It returns the
+// time spent waiting. A nil limiter never blocks.
+func (l *RateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+	delay := l.reserve()
+	if err := sleep(ctx, delay); err != nil {
+		l.release()
+		return 0, err
+	}
+	l.record(delay)
+	return delay, nil
+}
+
+// Rate returns the current rate of the limiter in calls per second. It is
+// lower than the configured rate after the service reported too many calls.
+func (l *RateLimiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// Stats returns the statistics of waits imposed by the limiter.
+func (l *RateLimiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// reserve takes a token from the bucket and returns how long the caller must
+// wait before the token becomes available.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens--; l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// release returns the token of a call that was abandoned while waiting.
+func (l *RateLimiter) release() {
+	l.mu.Lock()
+	l.tokens++
+	l.mu.Unlock()
+}
+
+// record updates the statistics with a call that waited for delay.
+func (l *RateLimiter) record(delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Calls++
+	if delay <= 0 {
+		return
+	}
+	l.stats.Delayed++
+	l.stats.Waited += delay
+	if delay > l.stats.MaxWait {
+		l.stats.MaxWait = delay
+	}
+}
+
+// observe adapts the rate of the limiter to the response of the service.
+func (l *RateLimiter) observe(resp *http.Response) {
+	if l == nil || resp == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		l.rate = math.Max(l.rate/2, l.limit*minRateFraction)
+		l.tokens = math.Min(l.tokens, 0)
+	case succeeded(resp):
+		l.rate = math.Min(l.rate+l.limit*recoveryStep, l.limit)
+	}
+}
+
+// limiterKey is the context key under which the rate limiter of a batch is
+// stored.
+type limiterKey struct{}
+
+// withLimiter returns a copy of ctx whose calls are subject to the limiter
+// instead of the ones in Client.Limits.
+func withLimiter(ctx context.Context, limiter *RateLimiter) context.Context {
+	return context.WithValue(ctx, limiterKey{}, limiter)
+}
+
+// limiterOf returns the rate limiter the call made with ctx is subject to.
+func (c *Client) limiterOf(ctx context.Context) *RateLimiter {
+	if limiter, ok := ctx.Value(limiterKey{}).(*RateLimiter); ok {
+		return limiter
+	}
+	return c.Limits[callOf(ctx).Method]
+}
+
+// attempt performs the request once, after waiting for the rate limiter of
+// the call.
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	limiter := c.limiterOf(req.Context())
+	if _, err := limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.Do(req)
+	limiter.observe(resp)
+	return resp, err
+}
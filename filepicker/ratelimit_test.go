@@ -0,0 +1,130 @@
+package filepicker_test
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := filepicker.NewRateLimiter(100, 2)
+	ctx := context.Background()
+	for i, want := range []bool{false, false, true} {
+		delay, err := limiter.Wait(ctx)
+		if err != nil {
+			t.Fatalf("want err == nil; got %v (i:%d)", err, i)
+		}
+		if delayed := delay > 0; delayed != want {
+			t.Errorf("want delayed == %t; got %v (i:%d)", want, delay, i)
+		}
+	}
+	stats := limiter.Stats()
+	if stats.Calls != 3 || stats.Delayed != 1 || stats.Waited <= 0 || stats.MaxWait != stats.Waited {
+		t.Errorf("want 3 calls with one delayed; got %+v", stats)
+	}
+}
+
+func TestRateLimiterCanceled(t *testing.T) {
+	limiter := filepicker.NewRateLimiter(1, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	if _, err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("want err == context.DeadlineExceeded; got %v", err)
+	}
+	if stats := limiter.Stats(); stats.Calls != 1 {
+		t.Errorf("want 1 call; got %+v", stats)
+	}
+}
+
+func TestClientLimits(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "GET" {
+			w.Write([]byte(`{"size":1}`))
+			return
+		}
+		w.Write([]byte("success"))
+	}
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+	limiter := filepicker.NewRateLimiter(20, 1)
+	client.Limits = map[filepicker.Method]*filepicker.RateLimiter{
+		filepicker.MetStat: limiter,
+	}
+	blob := filepicker.NewBlob(FakeHandle)
+
+	for i := 0; i < 3; i++ {
+		if err := client.Remove(blob, nil); err != nil {
+			t.Errorf("want err == nil; got %v (i:%d)", err, i)
+		}
+	}
+	if stats := limiter.Stats(); stats.Calls != 0 {
+		t.Errorf("want unlimited removes; got %+v", stats)
+	}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := client.Stat(blob, nil); err != nil {
+			t.Errorf("want err == nil; got %v (i:%d)", err, i)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("want elapsed >= 90ms; got %v", elapsed)
+	}
+	if stats := limiter.Stats(); stats.Calls != 3 || stats.Delayed != 2 {
+		t.Errorf("want 3 calls with two delayed; got %+v", stats)
+	}
+}
+
+func TestRateLimiterSlowDown(t *testing.T) {
+	status := http.StatusTooManyRequests
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte("Too many requests"))
+	}
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+	limiter := filepicker.NewRateLimiter(160, 10)
+	client.Limits = map[filepicker.Method]*filepicker.RateLimiter{
+		filepicker.MetRemove: limiter,
+	}
+	blob := filepicker.NewBlob(FakeHandle)
+
+	tests := []struct {
+		Status int
+		Rate   float64
+	}{
+		{http.StatusTooManyRequests, 80},
+		{http.StatusTooManyRequests, 40},
+		{http.StatusOK, 50},
+		{http.StatusOK, 60},
+		{http.StatusNotFound, 60},
+	}
+	for i, test := range tests {
+		status = test.Status
+		client.Remove(blob, nil)
+		if rate := limiter.Rate(); rate != test.Rate {
+			t.Errorf("want rate == %v; got %v (i:%d)", test.Rate, rate, i)
+		}
+	}
+}
+
+func TestNewRateLimiterInvalid(t *testing.T) {
+	for i, rate := range []float64{0, -1, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("want panic for rate %v; got none (i:%d)", rate, i)
+				}
+			}()
+			filepicker.NewRateLimiter(rate, 1)
+		}()
+	}
+}
@@ -1,6 +1,9 @@
 package filepicker
 
-import "net/url"
+import (
+	"context"
+	"net/url"
+)
 
 // RemoveOpts structure allows the user to set additional options when removing
 // the data.
@@ -19,6 +22,12 @@ func (ro *RemoveOpts) toValues() url.Values {
 
 // Remove is used to delete a file from Filepicker.io and any underlying storage.
 func (c *Client) Remove(src *Blob, opt *RemoveOpts) error {
+	return c.RemoveContext(context.Background(), src, opt)
+}
+
+// RemoveContext works like Remove but binds the request to the provided
+// context.
+func (c *Client) RemoveContext(ctx context.Context, src *Blob, opt *RemoveOpts) error {
 	blobURL, err := url.Parse(src.URL)
 	if err != nil {
 		return err
@@ -28,8 +37,11 @@ func (c *Client) Remove(src *Blob, opt *RemoveOpts) error {
 		values = opt.toValues()
 	}
 	values.Set("key", c.apiKey)
+	if err := c.sign(values, blobPolicy(MetRemove, src)); err != nil {
+		return err
+	}
 	blobURL.RawQuery = values.Encode()
-	resp, err := c.do("DELETE", blobURL.String(), "", nil)
+	resp, err := c.do(withCall(ctx, &Call{Method: MetRemove, Handle: src.Handle(), Opts: opt}), "DELETE", blobURL.String(), "", nil)
 	if err != nil {
 		return err
 	}
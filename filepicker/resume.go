@@ -0,0 +1,198 @@
+package filepicker
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// partSuffix is appended to the name of a file that is being downloaded.
+const partSuffix = ".part"
+
+// validatorSuffix is appended to the name of a ".part" file to name the file
+// that keeps the validator of the downloaded content.
+const validatorSuffix = ".validator"
+
+// resumeDownload downloads the blob to a ".part" file, continuing from where a
+// previous attempt stopped, and renames it once the content is verified.
+func (c *Client) resumeDownload(ctx context.Context, src *Blob, opt *DownloadOpts, filedir string) error {
+	checks, err := resumeChecks(opt)
+	if err != nil {
+		return err
+	}
+	md, err := c.StatContext(ctx, src, &StatOpts{
+		Tags:     append([]MetaTag{TagFilename}, newDigester(checks).metaTags()...),
+		Security: c.statSecurity(opt.Security, opt.VerifySecurity),
+	})
+	if err != nil {
+		return err
+	}
+	if _, ok := md.Md5Hash(); !ok && opt.Verify&VerifyMD5 == 0 {
+		checks &^= VerifyMD5
+	}
+	filename, _ := md.Filename()
+	name, err := filePath(src, filedir, filename)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(name+partSuffix, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	if err = c.fetchPart(ctx, src, opt, file, checks != 0); err == nil {
+		err = verifyFile(file, md, newDigester(checks))
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return finishPart(file.Name(), name, err)
+}
+
+// resumeChecks returns the checksums verified by a resumed download. Unless
+// the service decodes the data, MD5 is verified even if it was not requested,
+// so that a ".part" file without the validator can be continued.
+func resumeChecks(opt *DownloadOpts) (Checksum, error) {
+	if err := verifiable(opt.Verify, opt.Base64Decode); err != nil {
+		return 0, err
+	}
+	if opt.Base64Decode {
+		return opt.Verify, nil
+	}
+	return opt.Verify | VerifyMD5, nil
+}
+
+// finishPart renames the completed ".part" file to its final name. The file is
+// removed if its content failed the verification.
+func finishPart(part, name string, err error) error {
+	if _, ok := err.(ChecksumError); ok {
+		os.Remove(part)
+		os.Remove(part + validatorSuffix)
+		return err
+	}
+	if err == nil {
+		err = os.Rename(part, name)
+	}
+	if err == nil {
+		os.Remove(part + validatorSuffix)
+	}
+	return err
+}
+
+// fetchPart appends the missing content of the blob to the partially written
+// file. The file is downloaded from scratch if it is larger than the blob, if
+// the blob has changed since the file was started or if the file can be
+// neither validated nor verified.
+func (c *Client) fetchPart(ctx context.Context, src *Blob, opt *DownloadOpts, file *os.File, verified bool) error {
+	validator, err := partValidator(file, verified)
+	if err != nil {
+		return err
+	}
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	resp, err := c.download(ctx, src, partOpts(opt, offset, validator))
+	if rerr, ok := err.(RangeError); ok {
+		if rerr.Size == offset {
+			return nil
+		}
+		resp, err = c.download(ctx, src, partOpts(opt, 0, ""))
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		if err = startPart(file, resp); err != nil {
+			return err
+		}
+	}
+	_, err = io.Copy(file, downloadBody(resp, opt))
+	return err
+}
+
+// partValidator returns the validator of the partially written file. A file
+// without the validator is discarded unless its content will be verified.
+func partValidator(file *os.File, verified bool) (string, error) {
+	validator := readValidator(file.Name())
+	if validator == "" && !verified {
+		return "", restartPart(file)
+	}
+	return validator, nil
+}
+
+// startPart prepares the partially written file for the whole content of the
+// blob sent in the response.
+func startPart(file *os.File, resp *http.Response) error {
+	if err := restartPart(file); err != nil {
+		return err
+	}
+	return writeValidator(file.Name(), resp)
+}
+
+// partOpts returns a copy of download options that requests the content of
+// the blob starting at the given offset, provided that the blob still matches
+// the validator.
+func partOpts(opt *DownloadOpts, offset int64, validator string) *DownloadOpts {
+	partOpt := *opt
+	partOpt.Ranges = nil
+	if offset > 0 {
+		partOpt.Ranges = []ByteRange{{Offset: offset}}
+		partOpt.ifRange = validator
+	}
+	return &partOpt
+}
+
+// readValidator returns the validator saved for the ".part" file. It returns
+// an empty string if there is none.
+func readValidator(name string) string {
+	data, err := ioutil.ReadFile(name + validatorSuffix)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// writeValidator saves the validator of the response for the ".part" file. An
+// ETag is preferred over a modification time, but weak ETags cannot be used in
+// If-Range header.
+func writeValidator(name string, resp *http.Response) error {
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	if validator == "" {
+		if err := os.Remove(name + validatorSuffix); !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return ioutil.WriteFile(name+validatorSuffix, []byte(validator), 0666)
+}
+
+// restartPart discards the content of partially written file.
+func restartPart(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := file.Seek(0, io.SeekStart)
+	return err
+}
+
+// verifyFile hashes the whole file and compares the result with the hashes
+// stored in file metadata. A nil digester skips the check.
+func verifyFile(file *os.File, md Metadata, dg *digester) error {
+	if dg == nil {
+		return nil
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(dg, file); err != nil {
+		return err
+	}
+	return dg.verify(md)
+}
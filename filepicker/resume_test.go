@@ -0,0 +1,266 @@
+package filepicker_test
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+	"github.com/filepicker/filepicker-go/filepicker/filepickertest"
+)
+
+func resumeHandler(md5hash string, reqRange *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/metadata") {
+			w.Write([]byte(`{"filename":"document.txt","md5":"` + md5hash + `"}`))
+			return
+		}
+		*reqRange = req.Header.Get("Range")
+		http.ServeContent(w, req, "", time.Time{}, strings.NewReader(downloadFileContent))
+	}
+}
+
+func TestDownloadToFileResume(t *testing.T) {
+	sum := md5.Sum([]byte(downloadFileContent))
+	md5hash := hex.EncodeToString(sum[:])
+	tests := []struct {
+		Part  string
+		Range string
+	}{
+		{
+			Part:  "",
+			Range: "",
+		},
+		{
+			Part:  "DOWN",
+			Range: "bytes=4-",
+		},
+		{
+			Part:  downloadFileContent,
+			Range: "bytes=12-",
+		},
+		{
+			Part:  downloadFileContent + "GARBAGE",
+			Range: "",
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "FP")
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var reqRange string
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, resumeHandler(md5hash, &reqRange))
+	defer mock.Close()
+
+	name := filepath.Join(dir, "document.txt")
+	for i, test := range tests {
+		if test.Part != "" {
+			if err := ioutil.WriteFile(name+".part", []byte(test.Part), 0666); err != nil {
+				t.Fatalf("want err == nil; got %v (i:%d)", err, i)
+			}
+		}
+		reqRange = ""
+		opt := &filepicker.DownloadOpts{Resume: true}
+		if err := client.DownloadToFile(blob, opt, dir+string(filepath.Separator)); err != nil {
+			t.Errorf("want err == nil; got %v (i:%d)", err, i)
+		}
+		if reqRange != test.Range {
+			t.Errorf("want reqRange == %q; got %q (i:%d)", test.Range, reqRange, i)
+		}
+		if b, _ := ioutil.ReadFile(name); string(b) != downloadFileContent {
+			t.Errorf("want content == %q; got %q (i:%d)", downloadFileContent, b, i)
+		}
+		if _, err := os.Stat(name + ".part"); !os.IsNotExist(err) {
+			t.Errorf("want .part file removed; got %v (i:%d)", err, i)
+		}
+		os.Remove(name)
+	}
+}
+
+func TestDownloadToFileResumeCorrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "FP")
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var reqRange string
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, resumeHandler("f31dbf9b885e315d98e136f1db0daf52", &reqRange))
+	defer mock.Close()
+
+	name := filepath.Join(dir, "file.txt")
+	if err := ioutil.WriteFile(name+".part", []byte("XXXX"), 0666); err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	opt := &filepicker.DownloadOpts{Resume: true}
+	if err := client.DownloadToFile(blob, opt, name); err == nil {
+		t.Error("want err != nil; got nil")
+	}
+	for _, path := range []string{name, name + ".part"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("want %s removed; got %v", path, err)
+		}
+	}
+}
+
+func validatorHandler(etag string, abort *bool, reqRange, reqIfRange *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/metadata") {
+			w.Write([]byte(`{"filename":"document.txt"}`))
+			return
+		}
+		*reqRange, *reqIfRange = req.Header.Get("Range"), req.Header.Get("If-Range")
+		w.Header().Set("ETag", etag)
+		if *abort {
+			*abort = false
+			w.Header().Set("Content-Length", strconv.Itoa(len(downloadFileContent)))
+			w.Write([]byte(downloadFileContent[:4]))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, req, "", time.Time{}, strings.NewReader(downloadFileContent))
+	}
+}
+
+func TestDownloadToFileResumeValidator(t *testing.T) {
+	const ETag = `"v2"`
+	tests := []struct {
+		Part      string
+		Validator string
+		Abort     bool
+		Range     string
+		IfRange   string
+	}{
+		{
+			Part:      "",
+			Validator: "",
+			Abort:     true,
+			Range:     "",
+			IfRange:   "",
+		},
+		{
+			Part:      "",
+			Validator: "",
+			Abort:     false,
+			Range:     "bytes=4-",
+			IfRange:   ETag,
+		},
+		{
+			Part:      "XXXX",
+			Validator: `"v1"`,
+			Abort:     false,
+			Range:     "bytes=4-",
+			IfRange:   `"v1"`,
+		},
+		{
+			Part:      "XXXX",
+			Validator: "",
+			Abort:     false,
+			Range:     "",
+			IfRange:   "",
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "FP")
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var abort bool
+	var reqRange, reqIfRange string
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, validatorHandler(ETag, &abort, &reqRange, &reqIfRange))
+	defer mock.Close()
+
+	name := filepath.Join(dir, "document.txt")
+	for i, test := range tests {
+		writePart(t, name, test.Part, test.Validator, i)
+		abort = test.Abort
+		opt := &filepicker.DownloadOpts{Resume: true}
+		err := client.DownloadToFile(blob, opt, dir+string(filepath.Separator))
+		if reqRange != test.Range || reqIfRange != test.IfRange {
+			t.Errorf("want range %q if %q; got %q if %q (i:%d)", test.Range, test.IfRange, reqRange, reqIfRange, i)
+		}
+		if !test.Abort {
+			checkResumed(t, name, err, i)
+			continue
+		}
+		if err == nil {
+			t.Errorf("want err != nil; got nil (i:%d)", i)
+		}
+		if b, _ := ioutil.ReadFile(name + ".part.validator"); string(b) != ETag {
+			t.Errorf("want validator == %q; got %q (i:%d)", ETag, b, i)
+		}
+	}
+}
+
+// writePart creates the ".part" file and its validator unless they are empty.
+func writePart(t *testing.T, name, part, validator string, i int) {
+	if part != "" {
+		if err := ioutil.WriteFile(name+".part", []byte(part), 0666); err != nil {
+			t.Fatalf("want err == nil; got %v (i:%d)", err, i)
+		}
+	}
+	if validator != "" {
+		if err := ioutil.WriteFile(name+".part.validator", []byte(validator), 0666); err != nil {
+			t.Fatalf("want err == nil; got %v (i:%d)", err, i)
+		}
+	}
+}
+
+// checkResumed checks that the resumed download completed and cleaned up its
+// ".part" files, and then removes the downloaded file.
+func checkResumed(t *testing.T, name string, err error, i int) {
+	if err != nil {
+		t.Errorf("want err == nil; got %v (i:%d)", err, i)
+	}
+	if b, _ := ioutil.ReadFile(name); string(b) != downloadFileContent {
+		t.Errorf("want content == %q; got %q (i:%d)", downloadFileContent, b, i)
+	}
+	for _, path := range []string{name + ".part", name + ".part.validator"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("want %s removed; got %v (i:%d)", path, err, i)
+		}
+	}
+	os.Remove(name)
+}
+
+func TestDownloadToFileResumeBase64(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	client := srv.Client()
+	blob := srv.Put("document.txt", []byte(base64.StdEncoding.EncodeToString([]byte(downloadFileContent))))
+
+	dir, err := ioutil.TempDir("", "FP")
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "document.txt")
+
+	opt := &filepicker.DownloadOpts{Resume: true, Base64Decode: true, Verify: filepicker.VerifyMD5}
+	if err := client.DownloadToFile(blob, opt, name); err != filepicker.ErrUnverifiable {
+		t.Errorf("want err == ErrUnverifiable; got %v", err)
+	}
+
+	opt.Verify = 0
+	writePart(t, name, downloadFileContent[:4], "", 0)
+	checkResumed(t, name, client.DownloadToFile(blob, opt, name), 0)
+}
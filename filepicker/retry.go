@@ -0,0 +1,236 @@
+package filepicker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy defines how a Client retries calls that failed due to transient
+// errors. Zero values of its fields are replaced with sensible defaults, so
+// &RetryPolicy{} is a valid policy.
+//
+// Calls are retried only when their request can be safely sent again. Calls
+// made with idempotent HTTP methods (Stat, downloads, Remove) are retried by
+// default. Calls made with POST method (stores, picks, writes and
+// ConvertAndStore) may have taken effect even though they failed, so they are
+// retried only if RetryNonIdempotent is set and their body can be replayed.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts made for a single call,
+	// including the first one. Defaults to 3.
+	MaxAttempts int
+
+	// MinBackoff is the base delay between two consecutive attempts. It is
+	// doubled after each failure. Defaults to 100 milliseconds.
+	MinBackoff time.Duration
+
+	// MaxBackoff limits the delay between two consecutive attempts. Delays
+	// requested by the service with Retry-After header are clamped to it as
+	// well. Defaults to 10 seconds.
+	MaxBackoff time.Duration
+
+	// Retryable reports whether a call that failed with err should be retried.
+	// The error is either an Fperror returned by filepicker service or an error
+	// returned by the underlying HTTP client. If this field is nil, the policy
+	// uses IsRetryable function.
+	Retryable func(err error) bool
+
+	// RetryNonIdempotent enables retries of calls made with POST method. A
+	// retried call may then store or convert the same file more than once.
+	RetryNonIdempotent bool
+}
+
+// IsRetryable reports whether err is likely to be a transient failure. Service
+// errors are considered transient when their code is one of 408, 429, 500,
+// 502, 503 or 504. Other errors are transient only if they are network errors
+// which timed out or are temporary, or if the connection was reset. Errors
+// caused by context cancellation, invalid certificates or malformed URLs are
+// never transient, and neither are the errors of this package which do not
+// come from the service, like RangeError or ChecksumError.
+func IsRetryable(err error) bool {
+	var (
+		fperr Fperror
+		nerr  net.Error
+	)
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &fperr):
+		return transientCodes[fperr.Code]
+	case errors.Is(err, syscall.ECONNRESET):
+		return true
+	case errors.As(err, &nerr):
+		return nerr.Timeout() || nerr.Temporary()
+	}
+	return false
+}
+
+// transientCodes lists the status codes of service errors worth retrying.
+var transientCodes = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// allows reports whether the policy permits the next attempt of the request.
+func (rp *RetryPolicy) allows(req *http.Request, attempt int) bool {
+	if rp == nil {
+		return false
+	}
+	maxAttempts := rp.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	return attempt < maxAttempts && (req.Body == nil || req.GetBody != nil) &&
+		(idempotent(req) || rp.RetryNonIdempotent)
+}
+
+// idempotent reports whether sending the request again has no other effect
+// than sending it once.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "PUT", "DELETE", "OPTIONS":
+		return true
+	}
+	return false
+}
+
+// retryable reports whether the call that failed with err should be retried.
+func (rp *RetryPolicy) retryable(err error) bool {
+	if rp.Retryable != nil {
+		return rp.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// backoff computes the delay before the next attempt. The value of Retry-After
+// response header takes precedence over the exponential backoff with jitter but
+// never exceeds MaxBackoff.
+func (rp *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	minBackoff, maxBackoff := rp.MinBackoff, rp.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = 100 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Second
+	}
+	if delay, ok := retryAfter(resp); ok {
+		if delay > maxBackoff {
+			return maxBackoff
+		}
+		return delay
+	}
+	delay := maxBackoff
+	if shift := uint(attempt - 1); shift < 32 && minBackoff<<shift < maxBackoff {
+		delay = minBackoff << shift
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter reads the delay from the Retry-After header of the response. The
+// header can contain either a number of seconds or an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// roundTrip performs the request, retrying it according to client's retry
+// policy, and logs the outcome.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	if c.Logger == nil {
+		resp, _, err := c.retry(req)
+		return resp, err
+	}
+	log := &callLog{logger: c.Logger, start: time.Now()}
+	resp, retries, err := c.retry(log.track(req))
+	return log.finish(resp, retries, err), err
+}
+
+// retry performs the request until it succeeds or the retry policy gives up.
+// It also returns the number of retries made.
+func (c *Client) retry(req *http.Request) (*http.Response, int, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(req)
+		if !c.Retry.allows(req, attempt) {
+			return resp, attempt - 1, err
+		}
+		failure := err
+		if failure == nil {
+			failure = peekError(resp)
+		}
+		if failure == nil || !c.Retry.retryable(failure) {
+			return resp, attempt - 1, err
+		}
+		delay := c.Retry.backoff(attempt, resp)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err := rewind(req); err != nil {
+			return nil, attempt - 1, err
+		}
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, attempt - 1, err
+		}
+	}
+}
+
+// peekError works like readError but leaves the response body intact so that
+// it can be read again by the caller.
+func peekError(resp *http.Response) error {
+	if succeeded(resp) {
+		return nil
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return newError(resp, data)
+}
+
+// rewind restores the body of the request so that it can be sent again.
+func rewind(req *http.Request) (err error) {
+	if req.GetBody != nil {
+		req.Body, err = req.GetBody()
+	}
+	return
+}
+
+// sleep pauses the current goroutine for at least the given duration or until
+// the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
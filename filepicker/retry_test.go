@@ -0,0 +1,215 @@
+package filepicker_test
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+)
+
+func flakyHandler(failures int, code int, calls *int, bodies *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		*bodies = append(*bodies, string(body))
+		if *calls++; *calls <= failures {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, dummyErrStr, code)
+			return
+		}
+		w.Write([]byte("{}"))
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		Failures int
+		Code     int
+		Policy   *filepicker.RetryPolicy
+		Calls    int
+		Err      error
+	}{
+		{
+			Failures: 2,
+			Code:     http.StatusServiceUnavailable,
+			Policy:   nil,
+			Calls:    1,
+			Err:      filepicker.Fperror{Code: 503, Message: dummyErrStr},
+		},
+		{
+			Failures: 2,
+			Code:     http.StatusServiceUnavailable,
+			Policy:   &filepicker.RetryPolicy{},
+			Calls:    3,
+			Err:      nil,
+		},
+		{
+			Failures: 3,
+			Code:     http.StatusBadGateway,
+			Policy:   &filepicker.RetryPolicy{},
+			Calls:    3,
+			Err:      filepicker.Fperror{Code: 502, Message: dummyErrStr},
+		},
+		{
+			Failures: 1,
+			Code:     http.StatusNotFound,
+			Policy:   &filepicker.RetryPolicy{},
+			Calls:    1,
+			Err:      filepicker.Fperror{Code: 404, Message: dummyErrStr},
+		},
+		{
+			Failures: 1,
+			Code:     http.StatusRequestedRangeNotSatisfiable,
+			Policy:   &filepicker.RetryPolicy{},
+			Calls:    1,
+			Err:      filepicker.RangeError{Size: -1},
+		},
+		{
+			Failures: 1,
+			Code:     http.StatusNotFound,
+			Policy: &filepicker.RetryPolicy{
+				Retryable: func(err error) bool {
+					fperr, ok := err.(filepicker.Fperror)
+					return ok && fperr.Code == http.StatusNotFound
+				},
+			},
+			Calls: 2,
+			Err:   nil,
+		},
+	}
+
+	blob := filepicker.NewBlob(FakeHandle)
+	for i, test := range tests {
+		var calls int
+		var bodies []string
+		client := filepicker.NewClient(FakeApiKey)
+		client.Retry = test.Policy
+		mock := MockServer(t, client, flakyHandler(test.Failures, test.Code, &calls, &bodies))
+
+		if _, err := client.Stat(blob, nil); !errors.Is(err, test.Err) {
+			t.Errorf("want err == %v; got %v (i:%d)", test.Err, err, i)
+		}
+		if calls != test.Calls {
+			t.Errorf("want calls == %d; got %d (i:%d)", test.Calls, calls, i)
+		}
+		mock.Close()
+	}
+}
+
+func TestRetryReplayBody(t *testing.T) {
+	const TestURL = "https://www.filepicker.com/image.png"
+	var calls int
+	var bodies []string
+	client := filepicker.NewClient(FakeApiKey)
+	client.Retry = &filepicker.RetryPolicy{MinBackoff: time.Millisecond, RetryNonIdempotent: true}
+	mock := MockServer(t, client, flakyHandler(1, http.StatusTooManyRequests, &calls, &bodies))
+	defer mock.Close()
+
+	if _, err := client.StoreURL(TestURL, nil); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("want calls == 2; got %d", calls)
+	}
+	for i, body := range bodies {
+		if !strings.Contains(body, "url=") {
+			t.Errorf("want body with url; got %q (i:%d)", body, i)
+		}
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	tests := []struct {
+		Policy *filepicker.RetryPolicy
+		Calls  int
+	}{
+		{&filepicker.RetryPolicy{MinBackoff: time.Millisecond}, 1},
+		{&filepicker.RetryPolicy{MinBackoff: time.Millisecond, RetryNonIdempotent: true}, 3},
+	}
+
+	for i, test := range tests {
+		var calls int
+		client := filepicker.NewClient(FakeApiKey)
+		client.Retry = test.Policy
+		client.Client.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return nil, &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+		})
+
+		if _, err := client.StoreURL("https://www.filepicker.com/image.png", nil); err == nil {
+			t.Errorf("want err != nil; got nil (i:%d)", i)
+		}
+		if calls != test.Calls {
+			t.Errorf("want calls == %d; got %d (i:%d)", test.Calls, calls, i)
+		}
+	}
+}
+
+func TestRetryAfterLimit(t *testing.T) {
+	var calls int
+	client := filepicker.NewClient(FakeApiKey)
+	client.Retry = &filepicker.RetryPolicy{MaxBackoff: 10 * time.Millisecond}
+	mock := MockServer(t, client, func(w http.ResponseWriter, req *http.Request) {
+		if calls++; calls == 1 {
+			w.Header().Set("Retry-After", "86400")
+			http.Error(w, dummyErrStr, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("{}"))
+	})
+	defer mock.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.StatContext(ctx, filepicker.NewBlob(FakeHandle), nil); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("want calls == 2; got %d", calls)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		Err       error
+		Retryable bool
+	}{
+		{nil, false},
+		{filepicker.Fperror{Code: 429}, true},
+		{filepicker.Fperror{Code: 503}, true},
+		{filepicker.Fperror{Code: 400}, false},
+		{filepicker.Fperror{Code: 403}, false},
+		{errors.New("connection reset by peer"), false},
+		{&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{&url.Error{Op: "Get", URL: "https://x", Err: timeoutError{}}, true},
+		{&url.Error{Op: "Get", URL: "https://x", Err: x509.UnknownAuthorityError{}}, false},
+		{&url.Error{Op: "parse", URL: ":", Err: errors.New("missing protocol scheme")}, false},
+		{context.Canceled, false},
+		{filepicker.RangeError{Size: 10}, false},
+		{filepicker.ChecksumError{Tag: filepicker.TagMd5Hash}, false},
+		{filepicker.PolicyError{Reason: dummyErrStr}, false},
+		{fmt.Errorf("wrapped: %w", filepicker.Fperror{Code: 502}), true},
+	}
+
+	for i, test := range tests {
+		if retryable := filepicker.IsRetryable(test.Err); retryable != test.Retryable {
+			t.Errorf("want retryable == %t; got %t (i:%d)", test.Retryable, retryable, i)
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
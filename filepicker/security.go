@@ -7,6 +7,11 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"mime"
+	"net"
+	"path"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -41,6 +46,28 @@ type PolicyOpts struct {
 	// perl-like regular expression that must match the container that the files
 	// will be stored under. Defaults to allowing any container ('.*').
 	Container string `json:"container,omitempty"`
+
+	// URL field is valid only for policies that store files fetched from other
+	// addresses (see StoreURL, PickURL and WriteURL). It is a perl-like regular
+	// expression that must match the address of the data.
+	URL string `json:"url,omitempty"`
+
+	// Mimetypes lists the types of files that can be uploaded. An entry may
+	// use a wildcard subtype, like "image/*". This property only applies to
+	// the calls that upload files.
+	Mimetypes []string `json:"mimetypes,omitempty"`
+
+	// IP lists the addresses the calls can be made from. Each entry is either
+	// a single address or a range in CIDR notation, like "10.0.0.0/8".
+	IP []string `json:"ip,omitempty"`
+
+	// MaxWidth sets the maximum width of converted images, in pixels. This
+	// property only applies to the convert command.
+	MaxWidth int `json:"maxwidth,omitempty"`
+
+	// MaxHeight sets the maximum height of converted images, in pixels. This
+	// property only applies to the convert command.
+	MaxHeight int `json:"maxheight,omitempty"`
 }
 
 // Method defines the calls that created policy will be able to make.
@@ -68,13 +95,145 @@ func (po *PolicyOpts) MarshalJSON() ([]byte, error) {
 	}{*po, po.Expiry.Unix()})
 }
 
+// UnmarshalJSON implements json.Unmarshaler interface. It reads Expiry field
+// from UNIX time value.
+func (po *PolicyOpts) UnmarshalJSON(data []byte) error {
+	type policyOpts PolicyOpts // Drops the methods of PolicyOpts.
+	aux := struct {
+		*policyOpts
+		ExpiryUNIX int64 `json:"expiry"`
+	}{policyOpts: (*policyOpts)(po)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	po.Expiry = time.Unix(aux.ExpiryUNIX, 0)
+	return nil
+}
+
+// Allows reports whether the policy permits a call of the given method on the
+// file with the given handle. The size, the path and the container of the file
+// are checked only for MetStore calls. Expired policies allow nothing.
+func (po *PolicyOpts) Allows(method Method, handle string, size uint64, path, container string) bool {
+	if time.Now().After(po.Expiry) || !po.allowsCall(method) {
+		return false
+	}
+	if po.Handle != "" && po.Handle != handle {
+		return false
+	}
+	if method != MetStore {
+		return true
+	}
+	if (po.MaxSize != 0 && size > po.MaxSize) || size < po.MinSize {
+		return false
+	}
+	return matchFull(po.Path, path) && matchFull(po.Container, container)
+}
+
+// allowsCall reports whether the method is on the list of allowed calls. An
+// empty list allows all calls.
+func (po *PolicyOpts) allowsCall(method Method) bool {
+	if len(po.Call) == 0 {
+		return true
+	}
+	for _, call := range po.Call {
+		if call == method {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsURL reports whether the policy permits storing the data fetched from
+// the given address.
+func (po *PolicyOpts) AllowsURL(dataURL string) bool {
+	return matchFull(po.URL, dataURL)
+}
+
+// AllowsMimetype reports whether the policy permits uploading a file of the
+// given type. Parameters of the type, like charset, are ignored. An empty list
+// of mimetypes allows any type.
+func (po *PolicyOpts) AllowsMimetype(mimetype string) bool {
+	if len(po.Mimetypes) == 0 {
+		return true
+	}
+	if mediatype, _, err := mime.ParseMediaType(mimetype); err == nil {
+		mimetype = mediatype
+	}
+	for _, pattern := range po.Mimetypes {
+		if ok, _ := path.Match(pattern, mimetype); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIP reports whether the policy permits calls from the given address.
+// An empty list of addresses allows calls from anywhere.
+func (po *PolicyOpts) AllowsIP(ip net.IP) bool {
+	if len(po.IP) == 0 {
+		return true
+	}
+	for _, entry := range po.IP {
+		if _, ipnet, err := net.ParseCIDR(entry); err == nil && ipnet.Contains(ip) {
+			return true
+		}
+		if net.ParseIP(entry).Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsConversion reports whether the policy permits converting an image to
+// the given dimensions. Zero dimensions are not checked.
+func (po *PolicyOpts) AllowsConversion(width, height int) bool {
+	return withinLimit(width, po.MaxWidth) && withinLimit(height, po.MaxHeight)
+}
+
+// withinLimit reports whether the value does not exceed the limit. A zero
+// limit means no limit.
+func withinLimit(value, limit int) bool {
+	return limit == 0 || value <= limit
+}
+
+// matchFull reports whether the pattern matches the whole value. An empty
+// pattern matches any value while an invalid one matches nothing.
+func matchFull(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	return err == nil && re.MatchString(value)
+}
+
 // Policy stores the information about what the user can or cannot do.
 type Policy string
 
-// MakePolicy creates a new Policy object from provided policy options.
+// ParsePolicy decodes policy options from the given policy.
+func ParsePolicy(policy Policy) (*PolicyOpts, error) {
+	byted, err := base64.URLEncoding.DecodeString(string(policy))
+	if err != nil {
+		return nil, PolicyError{Reason: "malformed policy encoding"}
+	}
+	po := &PolicyOpts{}
+	if err := json.Unmarshal(byted, po); err != nil {
+		return nil, PolicyError{Reason: "malformed policy content"}
+	}
+	if po.Expiry.Unix() == 0 {
+		return nil, PolicyError{Reason: "invalid expiration date"}
+	}
+	return po, nil
+}
+
+// MakePolicy creates a new Policy object from provided policy options. It
+// returns PolicyError if the options are incompatible with each other, like a
+// Handle set for the store call which creates new files.
 func MakePolicy(po *PolicyOpts) (policy Policy, err error) {
 	if po == nil || po.Expiry.IsZero() {
-		return policy, fmt.Errorf("filepicker: invalid expiration date")
+		return policy, PolicyError{Reason: "invalid expiration date"}
+	}
+	if err = po.validate(); err != nil {
+		return
 	}
 	byted, err := json.Marshal(po)
 	if err != nil {
@@ -83,6 +242,119 @@ func MakePolicy(po *PolicyOpts) (policy Policy, err error) {
 	return Policy(base64.URLEncoding.EncodeToString(byted)), nil
 }
 
+// validate checks whether the policy options are consistent.
+func (po *PolicyOpts) validate() error {
+	for _, check := range []func() string{po.checkCalls, po.checkPatterns, po.checkLimits} {
+		if reason := check(); reason != "" {
+			return PolicyError{Reason: reason}
+		}
+	}
+	return nil
+}
+
+// checkCalls verifies that the options restrict only the allowed calls.
+func (po *PolicyOpts) checkCalls() string {
+	if po.Handle != "" && po.mentions(MetStore, MetPick) {
+		return "handle cannot be set for store or pick calls"
+	}
+	scopes := []struct {
+		set    bool
+		calls  []Method
+		reason string
+	}{
+		{po.limitsStore(), []Method{MetStore},
+			"size, path and container limits require the store call"},
+		{po.URL != "", []Method{MetStore, MetPick, MetWriteurl},
+			"url restriction requires the store, pick or writeUrl call"},
+		{len(po.Mimetypes) != 0, []Method{MetStore, MetPick, MetWrite, MetWriteurl},
+			"mimetype restriction requires a call that uploads files"},
+		{po.limitsConvert(), []Method{MetConvert},
+			"conversion limits require the convert call"},
+	}
+	for _, scope := range scopes {
+		if scope.set && po.excludes(scope.calls...) {
+			return scope.reason
+		}
+	}
+	return ""
+}
+
+// checkPatterns verifies that the patterns and the addresses can be parsed.
+func (po *PolicyOpts) checkPatterns() string {
+	for _, pattern := range [][2]string{{"path", po.Path}, {"container", po.Container}, {"url", po.URL}} {
+		if _, err := regexp.Compile(pattern[1]); err != nil {
+			return "invalid " + pattern[0] + " pattern: " + err.Error()
+		}
+	}
+	for _, mimetype := range po.Mimetypes {
+		if _, err := path.Match(mimetype, ""); err != nil || !strings.Contains(mimetype, "/") {
+			return fmt.Sprintf("invalid mimetype %q", mimetype)
+		}
+	}
+	for _, entry := range po.IP {
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			return fmt.Sprintf("invalid IP address or range %q", entry)
+		}
+	}
+	return ""
+}
+
+// checkLimits verifies that the numeric limits make sense.
+func (po *PolicyOpts) checkLimits() string {
+	switch {
+	case po.MaxSize != 0 && po.MinSize > po.MaxSize:
+		return "minimum size exceeds maximum size"
+	case po.MaxWidth < 0 || po.MaxHeight < 0:
+		return "negative conversion limit"
+	}
+	return ""
+}
+
+// limitsStore reports whether any of the store limits is set.
+func (po *PolicyOpts) limitsStore() bool {
+	return po.MaxSize != 0 || po.MinSize != 0 || po.Path != "" || po.Container != ""
+}
+
+// limitsConvert reports whether any of the conversion limits is set.
+func (po *PolicyOpts) limitsConvert() bool {
+	return po.MaxWidth != 0 || po.MaxHeight != 0
+}
+
+// mentions reports whether any of the methods is explicitly listed in the
+// allowed calls.
+func (po *PolicyOpts) mentions(methods ...Method) bool {
+	for _, method := range methods {
+		for _, call := range po.Call {
+			if call == method {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// excludes reports whether the list of allowed calls is set and contains none
+// of the methods.
+func (po *PolicyOpts) excludes(methods ...Method) bool {
+	return len(po.Call) != 0 && !po.mentions(methods...)
+}
+
+// PolicyError describes why policy options are invalid. It matches
+// ErrInvalidPolicy error.
+type PolicyError struct {
+	Reason string
+}
+
+// Error satisfies builtin.error interface.
+func (e PolicyError) Error() string {
+	return "filepicker: " + e.Reason
+}
+
+// Unwrap returns ErrInvalidPolicy.
+func (e PolicyError) Unwrap() error {
+	return ErrInvalidPolicy
+}
+
 // Security type stores the piece of information that is required to access
 // secured URLs.
 type Security struct {
@@ -96,10 +368,25 @@ type Security struct {
 // You should not store your secret in your code. Instead, call this function
 // once and then use obtained strings to initialize Security objects directly.
 func MakeSecurity(secret string, policy Policy) Security {
-	hasher := hmac.New(sha256.New, []byte(secret))
-	hasher.Write([]byte(policy))
 	return Security{
 		Policy:    policy,
-		Signature: hex.EncodeToString(hasher.Sum(nil)),
+		Signature: hex.EncodeToString(sign(secret, policy)),
+	}
+}
+
+// Verify reports whether the signature was created from the policy with the
+// given secret. Signatures are compared in constant time.
+func (s Security) Verify(secret string) bool {
+	signature, err := hex.DecodeString(s.Signature)
+	if err != nil {
+		return false
 	}
+	return hmac.Equal(signature, sign(secret, s.Policy))
+}
+
+// sign computes HMAC-SHA256 of the policy using the given secret.
+func sign(secret string, policy Policy) []byte {
+	hasher := hmac.New(sha256.New, []byte(secret))
+	hasher.Write([]byte(policy))
+	return hasher.Sum(nil)
 }
@@ -1,6 +1,9 @@
 package filepicker_test
 
 import (
+	"errors"
+	"net"
+	"reflect"
 	"testing"
 	"time"
 
@@ -64,3 +67,290 @@ func TestSecurityErrorNoTime(t *testing.T) {
 		t.Errorf("want err != nil; got nil")
 	}
 }
+
+func TestSecurityErrorKind(t *testing.T) {
+	_, err := filepicker.MakePolicy(nil)
+	if !errors.Is(err, filepicker.ErrInvalidPolicy) {
+		t.Errorf("want errors.Is(err, ErrInvalidPolicy) == true; got %v", err)
+	}
+	if _, ok := err.(filepicker.PolicyError); !ok {
+		t.Errorf("want PolicyError; got %T", err)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		Policy filepicker.Policy
+		Opt    *filepicker.PolicyOpts
+	}{
+		{
+			Policy: "eyJoYW5kbGUiOiJLVzlFSmhZdFM2eTQ4V2htMlM2RCIsImV4cGlyeSI6MTUwODE0MTUwNH0=",
+			Opt: &filepicker.PolicyOpts{
+				Expiry: time.Unix(1508141504, 0),
+				Handle: "KW9EJhYtS6y48Whm2S6D",
+			},
+		},
+		{
+			Policy: "eyJjYWxsIjpbInN0b3JlIiwid3JpdGUiXSwiZXhwaXJ5IjoxNTA4MTU0MzIxfQ==",
+			Opt: &filepicker.PolicyOpts{
+				Expiry: time.Unix(1508154321, 0),
+				Call: []filepicker.Method{
+					filepicker.MetStore,
+					filepicker.MetWrite,
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		opt, err := filepicker.ParsePolicy(test.Policy)
+		if err != nil {
+			t.Fatalf("want err == nil; got %v (i:%d)", err, i)
+		}
+		if !opt.Expiry.Equal(test.Opt.Expiry) {
+			t.Errorf("want opt.Expiry == %v; got %v (i:%d)", test.Opt.Expiry, opt.Expiry, i)
+		}
+		opt.Expiry = test.Opt.Expiry
+		if !reflect.DeepEqual(opt, test.Opt) {
+			t.Errorf("want opt == %+v; got %+v (i:%d)", test.Opt, opt, i)
+		}
+	}
+}
+
+func TestParsePolicyError(t *testing.T) {
+	for i, policy := range []filepicker.Policy{
+		"!!!",
+		"bm90IGpzb24=",
+		"eyJoYW5kbGUiOiJLVzlFSmhZdFM2eTQ4V2htMlM2RCJ9",
+	} {
+		if _, err := filepicker.ParsePolicy(policy); !errors.Is(err, filepicker.ErrInvalidPolicy) {
+			t.Errorf("want errors.Is(err, ErrInvalidPolicy) == true; got %v (i:%d)", err, i)
+		}
+	}
+}
+
+func TestSecurityVerify(t *testing.T) {
+	const secret = "Z3IYZSH2UJA7VN3QYFVSVCF7PI"
+	security := filepicker.Security{
+		Policy:    "eyJoYW5kbGUiOiJLVzlFSmhZdFM2eTQ4V2htMlM2RCIsImV4cGlyeSI6MTUwODE0MTUwNH0=",
+		Signature: "4098f262b9dba23e4766ce127353aaf4f37fde0fd726d164d944e031fd862c18",
+	}
+	if !security.Verify(secret) {
+		t.Error("want security.Verify(secret) == true; got false")
+	}
+	if security.Verify("S4IXZSH2UJA7VN3QYFVSVCF7PI") {
+		t.Error("want security.Verify(other) == false; got true")
+	}
+	security.Signature = "not hex"
+	if security.Verify(secret) {
+		t.Error("want security.Verify(secret) == false; got true")
+	}
+}
+
+func TestPolicyAllows(t *testing.T) {
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+	tests := []struct {
+		Opt       filepicker.PolicyOpts
+		Method    filepicker.Method
+		Handle    string
+		Size      uint64
+		Path      string
+		Container string
+		Allows    bool
+	}{
+		{
+			Opt:    filepicker.PolicyOpts{Expiry: future},
+			Method: filepicker.MetRead,
+			Handle: "KW9EJhYtS6y48Whm2S6D",
+			Allows: true,
+		},
+		{
+			Opt:    filepicker.PolicyOpts{Expiry: past},
+			Method: filepicker.MetRead,
+			Handle: "KW9EJhYtS6y48Whm2S6D",
+			Allows: false,
+		},
+		{
+			Opt:    filepicker.PolicyOpts{Expiry: future, Handle: "KW9EJhYtS6y48Whm2S6D"},
+			Method: filepicker.MetRemove,
+			Handle: "XXXXXXXXXXXXXXXXXXXX",
+			Allows: false,
+		},
+		{
+			Opt: filepicker.PolicyOpts{
+				Expiry: future,
+				Call:   []filepicker.Method{filepicker.MetRead, filepicker.MetStat},
+			},
+			Method: filepicker.MetStat,
+			Allows: true,
+		},
+		{
+			Opt: filepicker.PolicyOpts{
+				Expiry: future,
+				Call:   []filepicker.Method{filepicker.MetRead, filepicker.MetStat},
+			},
+			Method: filepicker.MetWrite,
+			Allows: false,
+		},
+		{
+			Opt:    filepicker.PolicyOpts{Expiry: future, MaxSize: 100, MinSize: 10},
+			Method: filepicker.MetStore,
+			Size:   50,
+			Allows: true,
+		},
+		{
+			Opt:    filepicker.PolicyOpts{Expiry: future, MaxSize: 100},
+			Method: filepicker.MetStore,
+			Size:   101,
+			Allows: false,
+		},
+		{
+			Opt:    filepicker.PolicyOpts{Expiry: future, MinSize: 10},
+			Method: filepicker.MetStore,
+			Size:   9,
+			Allows: false,
+		},
+		{
+			Opt:       filepicker.PolicyOpts{Expiry: future, Path: "uploads/.*", Container: "bucket"},
+			Method:    filepicker.MetStore,
+			Path:      "uploads/image.png",
+			Container: "bucket",
+			Allows:    true,
+		},
+		{
+			Opt:    filepicker.PolicyOpts{Expiry: future, Path: "uploads/.*"},
+			Method: filepicker.MetStore,
+			Path:   "private/uploads/image.png",
+			Allows: false,
+		},
+		{
+			Opt:       filepicker.PolicyOpts{Expiry: future, Container: "bucket"},
+			Method:    filepicker.MetStore,
+			Container: "other",
+			Allows:    false,
+		},
+	}
+
+	for i, test := range tests {
+		allows := test.Opt.Allows(test.Method, test.Handle, test.Size, test.Path, test.Container)
+		if allows != test.Allows {
+			t.Errorf("want allows == %t; got %t (i:%d)", test.Allows, allows, i)
+		}
+	}
+}
+
+func TestMakePolicyValidation(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		Opt   filepicker.PolicyOpts
+		Valid bool
+	}{
+		{
+			Opt:   filepicker.PolicyOpts{Handle: "KW9EJhYtS6y48Whm2S6D", Call: []filepicker.Method{filepicker.MetRead}},
+			Valid: true,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{Handle: "KW9EJhYtS6y48Whm2S6D", Call: []filepicker.Method{filepicker.MetStore}},
+			Valid: false,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{Path: "uploads/.*", MaxSize: 10, Call: []filepicker.Method{filepicker.MetStore}},
+			Valid: true,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{Path: "uploads/.*", Call: []filepicker.Method{filepicker.MetRead}},
+			Valid: false,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{MinSize: 11, MaxSize: 10},
+			Valid: false,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{Path: "uploads/(.*"},
+			Valid: false,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{URL: `https://example\.com/.*`, Call: []filepicker.Method{filepicker.MetPick}},
+			Valid: true,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{URL: `https://example\.com/.*`, Call: []filepicker.Method{filepicker.MetWrite}},
+			Valid: false,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{Mimetypes: []string{"image/*", "text/plain"}, Call: []filepicker.Method{filepicker.MetWrite}},
+			Valid: true,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{Mimetypes: []string{"image/*"}, Call: []filepicker.Method{filepicker.MetRead}},
+			Valid: false,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{Mimetypes: []string{"image"}},
+			Valid: false,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{IP: []string{"10.0.0.0/8", "192.168.1.1", "::1"}},
+			Valid: true,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{IP: []string{"10.0.0.0/33"}},
+			Valid: false,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{MaxWidth: 800, Call: []filepicker.Method{filepicker.MetConvert}},
+			Valid: true,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{MaxHeight: 800, Call: []filepicker.Method{filepicker.MetStore}},
+			Valid: false,
+		},
+		{
+			Opt:   filepicker.PolicyOpts{MaxWidth: -1},
+			Valid: false,
+		},
+	}
+
+	for i, test := range tests {
+		test.Opt.Expiry = future
+		_, err := filepicker.MakePolicy(&test.Opt)
+		if test.Valid && err != nil {
+			t.Errorf("want err == nil; got %v (i:%d)", err, i)
+		}
+		if !test.Valid && !errors.Is(err, filepicker.ErrInvalidPolicy) {
+			t.Errorf("want errors.Is(err, ErrInvalidPolicy) == true; got %v (i:%d)", err, i)
+		}
+	}
+}
+
+func TestPolicyRestrictions(t *testing.T) {
+	po := filepicker.PolicyOpts{
+		URL:       `https://example\.com/.*`,
+		Mimetypes: []string{"image/*", "text/plain"},
+		IP:        []string{"10.0.0.0/8", "192.168.1.1"},
+		MaxWidth:  800,
+	}
+	tests := []struct {
+		Allows bool
+		Want   bool
+	}{
+		{po.AllowsURL("https://example.com/a.png"), true},
+		{po.AllowsURL("https://example.org/a.png"), false},
+		{po.AllowsMimetype("image/png"), true},
+		{po.AllowsMimetype("text/plain"), true},
+		{po.AllowsMimetype("text/html"), false},
+		{po.AllowsIP(net.ParseIP("10.1.2.3")), true},
+		{po.AllowsIP(net.ParseIP("192.168.1.1")), true},
+		{po.AllowsIP(net.ParseIP("192.168.1.2")), false},
+		{po.AllowsConversion(800, 5000), true},
+		{po.AllowsConversion(801, 0), false},
+		{(&filepicker.PolicyOpts{}).AllowsIP(net.ParseIP("192.168.1.2")), true},
+		{(&filepicker.PolicyOpts{}).AllowsMimetype("text/html"), true},
+	}
+
+	for i, test := range tests {
+		if test.Allows != test.Want {
+			t.Errorf("want allows == %t; got %t (i:%d)", test.Want, test.Allows, i)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package filepicker
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultPolicyTTL is the lifetime of policies created by a Client whose
+// PolicyTTL field is not set.
+const DefaultPolicyTTL = 5 * time.Minute
+
+// Signer creates security parameters for the calls made by a Client. The
+// provided policy allows only a single call and has its expiration date set.
+type Signer interface {
+	Sign(po *PolicyOpts) (Security, error)
+}
+
+// SecretSigner is a Signer that signs policies with the application secret
+// taken from the developer portal.
+type SecretSigner string
+
+// Sign implements Signer interface.
+func (s SecretSigner) Sign(po *PolicyOpts) (Security, error) {
+	policy, err := MakePolicy(po)
+	if err != nil {
+		return Security{}, err
+	}
+	return MakeSecurity(string(s), policy), nil
+}
+
+// policyTTL returns the lifetime of policies created by the client.
+func (c *Client) policyTTL() time.Duration {
+	if c.PolicyTTL > 0 {
+		return c.PolicyTTL
+	}
+	return DefaultPolicyTTL
+}
+
+// sign adds a policy and its signature to values unless they already carry
+// one or the client has no Signer. The policy allows only the call described
+// by po and expires after the client's PolicyTTL.
+func (c *Client) sign(values url.Values, po *PolicyOpts) error {
+	if c.Signer == nil || values.Get("policy") != "" {
+		return nil
+	}
+	if po.Expiry.IsZero() {
+		po.Expiry = time.Now().Add(c.policyTTL())
+	}
+	sec, err := c.Signer.Sign(po)
+	if err != nil {
+		return err
+	}
+	values.Set("policy", string(sec.Policy))
+	values.Set("signature", sec.Signature)
+	return nil
+}
+
+// signURL works like sign but operates on the query of the provided URL.
+func (c *Client) signURL(u *url.URL, po *PolicyOpts) error {
+	if c.Signer == nil {
+		return nil
+	}
+	values := u.Query()
+	if err := c.sign(values, po); err != nil {
+		return err
+	}
+	u.RawQuery = values.Encode()
+	return nil
+}
+
+// blobPolicy returns a policy which allows to call method on src only.
+func blobPolicy(method Method, src *Blob) *PolicyOpts {
+	return &PolicyOpts{Call: []Method{method}, Handle: src.Handle()}
+}
+
+// storePolicy returns a policy which allows to store a file with provided
+// options only.
+func storePolicy(opt *StoreOpts) *PolicyOpts {
+	po := &PolicyOpts{Call: []Method{MetStore}}
+	if opt == nil {
+		return po
+	}
+	if opt.Path != "" {
+		po.Path = regexp.QuoteMeta(opt.Path)
+		if strings.HasSuffix(opt.Path, "/") {
+			po.Path += ".*"
+		}
+	}
+	if opt.Container != "" {
+		po.Container = regexp.QuoteMeta(opt.Container)
+	}
+	return po
+}
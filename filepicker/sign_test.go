@@ -0,0 +1,173 @@
+package filepicker_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+	"github.com/filepicker/filepicker-go/filepicker/filepickertest"
+)
+
+const FakeSecret = "Z3IYZSH2UJA7VN3QYFVSVCF7PI"
+
+func TestSignerRoundTrip(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	srv.Secret = FakeSecret
+	client := srv.Client()
+
+	if _, err := client.StoreReader("a.txt", strings.NewReader("A"), nil); !errors.Is(err, filepicker.ErrUnauthorized) {
+		t.Fatalf("want ErrUnauthorized; got %v", err)
+	}
+
+	client.Signer = filepicker.SecretSigner(FakeSecret)
+	blob, err := client.StoreReader("a.txt", strings.NewReader("A"), &filepicker.StoreOpts{Path: "docs/"})
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	if _, err := client.Stat(blob, nil); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if _, err := client.WriteReader(blob, strings.NewReader("B"), &filepicker.WriteOpts{Verify: filepicker.VerifyMD5}); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	var buff bytes.Buffer
+	if _, err := client.DownloadTo(blob, nil, &buff); err != nil || buff.String() != "B" {
+		t.Errorf("want content == %q, err == nil; got %q, %v", "B", buff.String(), err)
+	}
+	if _, err := client.ConvertAndStore(blob, &filepicker.ConvertOpts{Width: 10}); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if err := client.Remove(blob, nil); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+}
+
+func TestSignerPolicy(t *testing.T) {
+	blob := filepicker.NewBlob(FakeHandle)
+	tests := []struct {
+		Call   func(*filepicker.Client)
+		Method filepicker.Method
+		Handle string
+		Path   string
+	}{
+		{
+			Call: func(c *filepicker.Client) {
+				c.StoreReader("a.txt", strings.NewReader("A"), &filepicker.StoreOpts{Path: "my.dir/"})
+			},
+			Method: filepicker.MetStore,
+			Path:   `my\.dir/.*`,
+		},
+		{
+			Call:   func(c *filepicker.Client) { c.StoreURL("http://example.com", nil) },
+			Method: filepicker.MetStore,
+		},
+		{
+			Call:   func(c *filepicker.Client) { c.PickURL("http://example.com", nil) },
+			Method: filepicker.MetPick,
+		},
+		{
+			Call:   func(c *filepicker.Client) { c.Stat(blob, nil) },
+			Method: filepicker.MetStat,
+			Handle: FakeHandle,
+		},
+		{
+			Call:   func(c *filepicker.Client) { c.DownloadTo(blob, nil, &bytes.Buffer{}) },
+			Method: filepicker.MetRead,
+			Handle: FakeHandle,
+		},
+		{
+			Call:   func(c *filepicker.Client) { c.WriteReader(blob, strings.NewReader("A"), nil) },
+			Method: filepicker.MetWrite,
+			Handle: FakeHandle,
+		},
+		{
+			Call:   func(c *filepicker.Client) { c.WriteURL(blob, "http://example.com", nil) },
+			Method: filepicker.MetWriteurl,
+			Handle: FakeHandle,
+		},
+		{
+			Call:   func(c *filepicker.Client) { c.ConvertAndStore(blob, &filepicker.ConvertOpts{Width: 10}) },
+			Method: filepicker.MetConvert,
+			Handle: FakeHandle,
+		},
+		{
+			Call:   func(c *filepicker.Client) { c.Remove(blob, nil) },
+			Method: filepicker.MetRemove,
+			Handle: FakeHandle,
+		},
+	}
+	for i, test := range tests {
+		var security filepicker.Security
+		handler := func(w http.ResponseWriter, req *http.Request) {
+			security.Policy = filepicker.Policy(req.FormValue("policy"))
+			security.Signature = req.FormValue("signature")
+		}
+		client := filepicker.NewClient(FakeApiKey)
+		client.Signer = filepicker.SecretSigner(FakeSecret)
+		client.PolicyTTL = time.Minute
+		mock := MockServer(t, client, handler)
+		test.Call(client)
+		mock.Close()
+		if !security.Verify(FakeSecret) {
+			t.Errorf("want signed policy; got %+v (i:%d)", security, i)
+			continue
+		}
+		po, err := filepicker.ParsePolicy(security.Policy)
+		if err != nil {
+			t.Errorf("want err == nil; got %v (i:%d)", err, i)
+			continue
+		}
+		if len(po.Call) != 1 || po.Call[0] != test.Method {
+			t.Errorf("want call == [%s]; got %v (i:%d)", test.Method, po.Call, i)
+		}
+		if po.Handle != test.Handle {
+			t.Errorf("want handle == %q; got %q (i:%d)", test.Handle, po.Handle, i)
+		}
+		if po.Path != test.Path {
+			t.Errorf("want path == %q; got %q (i:%d)", test.Path, po.Path, i)
+		}
+		if ttl := time.Until(po.Expiry); ttl <= 0 || ttl > time.Minute {
+			t.Errorf("want expiry within a minute; got %v (i:%d)", po.Expiry, i)
+		}
+	}
+}
+
+func TestSignerKeepsSecurity(t *testing.T) {
+	var reqURL string
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		reqURL = req.URL.String()
+	}
+	client := filepicker.NewClient(FakeApiKey)
+	client.Signer = filepicker.SecretSigner(FakeSecret)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	client.Remove(filepicker.NewBlob(FakeHandle), &filepicker.RemoveOpts{Security: dummySecurity})
+	if want := "http://www.filepicker.io/api/file/2HHH3?key=0KKK1&policy=P&signature=S"; reqURL != want {
+		t.Errorf("want reqURL == %q; got %q", want, reqURL)
+	}
+}
+
+type failingSigner struct{}
+
+func (failingSigner) Sign(*filepicker.PolicyOpts) (filepicker.Security, error) {
+	return filepicker.Security{}, errors.New(dummyErrStr)
+}
+
+func TestSignerError(t *testing.T) {
+	client := filepicker.NewClient(FakeApiKey)
+	client.Signer = failingSigner{}
+	mock := MockServer(t, client, func(w http.ResponseWriter, req *http.Request) {
+		t.Error("want no request; got one")
+	})
+	defer mock.Close()
+
+	if _, err := client.Stat(filepicker.NewBlob(FakeHandle), nil); err == nil || err.Error() != dummyErrStr {
+		t.Errorf("want err == %q; got %v", dummyErrStr, err)
+	}
+}
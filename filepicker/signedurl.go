@@ -0,0 +1,87 @@
+package filepicker
+
+import (
+	"net/url"
+	"path"
+	"time"
+)
+
+// URLOpts structure allows the user to configure URLs created by SignedURL.
+type URLOpts struct {
+	// Convert, when set, makes the URL point to the file converted with the
+	// provided options. Storage and security options are ignored since the
+	// converted file is not stored.
+	Convert *ConvertOpts
+
+	// Download makes browsers save the file as an attachment instead of
+	// displaying it.
+	Download bool
+}
+
+// storeParams lists conversion parameters which have no meaning for files
+// converted on the fly.
+var storeParams = []string{
+	"storeLocation", "storePath", "storeContainer", "storeAccess",
+	"policy", "signature",
+}
+
+// toValues takes all non-zero values from provided URLOpts instance and puts
+// them to url.Values object.
+func (uo *URLOpts) toValues() url.Values {
+	values := url.Values{}
+	if uo.Convert != nil {
+		values = uo.Convert.toValues()
+		for _, param := range storeParams {
+			values.Del(param)
+		}
+	}
+	if uo.Download {
+		values.Set("dl", "true")
+	}
+	return values
+}
+
+// SignedURL returns the address of the file which can be handed out to
+// browsers. The address carries a policy, signed with the application secret,
+// which allows to read the file for ttl duration only. Serving files this way
+// does not require proxying their content through the application servers.
+func (b *Blob) SignedURL(secret string, ttl time.Duration, opt *URLOpts) (string, error) {
+	return b.signedURL(SecretSigner(secret), ttl, opt)
+}
+
+// SignedURL works like Blob.SignedURL but signs the policy with the client's
+// Signer. It returns ErrNoSigner if the client has no Signer.
+func (c *Client) SignedURL(src *Blob, ttl time.Duration, opt *URLOpts) (string, error) {
+	if c.Signer == nil {
+		return "", ErrNoSigner
+	}
+	return src.signedURL(c.Signer, ttl, opt)
+}
+
+func (b *Blob) signedURL(signer Signer, ttl time.Duration, opt *URLOpts) (string, error) {
+	if ttl <= 0 {
+		return "", PolicyError{Reason: "invalid expiration date"}
+	}
+	blobURL, err := url.Parse(b.URL)
+	if err != nil {
+		return "", err
+	}
+	po := blobPolicy(MetRead, b)
+	po.Expiry = time.Now().Add(ttl)
+	values := url.Values{}
+	if opt != nil {
+		values = opt.toValues()
+		if opt.Convert != nil {
+			blobURL.Path = path.Join(blobURL.Path, "convert")
+			po.Call = append(po.Call, MetConvert)
+		}
+	}
+	sec, err := signer.Sign(po)
+	if err != nil {
+		return "", err
+	}
+	values.Set("policy", string(sec.Policy))
+	values.Set("signature", sec.Signature)
+	blobURL.RawQuery = values.Encode()
+	return blobURL.String(), nil
+}
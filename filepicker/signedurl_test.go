@@ -0,0 +1,162 @@
+package filepicker_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filepicker/filepicker-go/filepicker"
+	"github.com/filepicker/filepicker-go/filepicker/filepickertest"
+)
+
+func TestSignedURL(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	srv.Secret = FakeSecret
+	blob := srv.Put("a.png", []byte("PNG"))
+
+	tests := []struct {
+		Opt         *filepicker.URLOpts
+		Path        string
+		Query       url.Values
+		Call        []filepicker.Method
+		Disposition string
+	}{
+		{
+			Opt:  nil,
+			Path: "/api/file/" + blob.Handle(),
+			Call: []filepicker.Method{filepicker.MetRead},
+		},
+		{
+			Opt:         &filepicker.URLOpts{Download: true},
+			Path:        "/api/file/" + blob.Handle(),
+			Query:       url.Values{"dl": {"true"}},
+			Call:        []filepicker.Method{filepicker.MetRead},
+			Disposition: `attachment; filename=a.png`,
+		},
+		{
+			Opt: &filepicker.URLOpts{
+				Convert: &filepicker.ConvertOpts{
+					Width:     100,
+					Location:  filepicker.Azure,
+					Security:  dummySecurity,
+					Container: "bucket",
+				},
+			},
+			Path:  "/api/file/" + blob.Handle() + "/convert",
+			Query: url.Values{"width": {"100"}},
+			Call:  []filepicker.Method{filepicker.MetRead, filepicker.MetConvert},
+		},
+	}
+	for i, test := range tests {
+		signed, err := blob.SignedURL(FakeSecret, time.Minute, test.Opt)
+		if err != nil {
+			t.Errorf("want err == nil; got %v (i:%d)", err, i)
+			continue
+		}
+		po := checkSignedURL(t, signed, test.Path, test.Query, i)
+		if po == nil {
+			continue
+		}
+		if po.Handle != blob.Handle() || len(po.Call) != len(test.Call) {
+			t.Errorf("want handle == %q, call == %v; got %q, %v (i:%d)", blob.Handle(), test.Call, po.Handle, po.Call, i)
+		}
+		checkSignedGet(t, signed, test.Disposition, i)
+	}
+}
+
+// checkSignedURL compares the path and the query of the signed URL with the
+// expected ones and returns its policy, or nil if the policy is not valid.
+func checkSignedURL(t *testing.T, signed, path string, want url.Values, i int) *filepicker.PolicyOpts {
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Errorf("want err == nil; got %v (i:%d)", err, i)
+		return nil
+	}
+	if u.Path != path {
+		t.Errorf("want path == %q; got %q (i:%d)", path, u.Path, i)
+	}
+	query := u.Query()
+	security := filepicker.Security{
+		Policy:    filepicker.Policy(query.Get("policy")),
+		Signature: query.Get("signature"),
+	}
+	query.Del("policy")
+	query.Del("signature")
+	if query.Encode() != want.Encode() {
+		t.Errorf("want query == %q; got %q (i:%d)", want.Encode(), query.Encode(), i)
+	}
+	po, err := filepicker.ParsePolicy(security.Policy)
+	if err != nil || !security.Verify(FakeSecret) {
+		t.Errorf("want valid policy; got %v, %v (i:%d)", security, err, i)
+		return nil
+	}
+	return po
+}
+
+// checkSignedGet fetches the content with the signed URL like a browser would.
+func checkSignedGet(t *testing.T, signed, disposition string, i int) {
+	resp, err := http.Get(signed)
+	if err != nil {
+		t.Errorf("want err == nil; got %v (i:%d)", err, i)
+		return
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(data) != "PNG" {
+		t.Errorf("want 200 PNG; got %d %q (i:%d)", resp.StatusCode, data, i)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); cd != disposition {
+		t.Errorf("want Content-Disposition == %q; got %q (i:%d)", disposition, cd, i)
+	}
+}
+
+func TestSignedURLOtherHandle(t *testing.T) {
+	srv := filepickertest.NewServer(FakeApiKey)
+	defer srv.Close()
+	srv.Secret = FakeSecret
+	blob := srv.Put("a.txt", []byte("A"))
+	other := srv.Put("b.txt", []byte("B"))
+
+	signed, err := blob.SignedURL(FakeSecret, time.Minute, nil)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	signed = strings.Replace(signed, blob.Handle(), other.Handle(), 1)
+	resp, err := http.Get(signed)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("want status == %d; got %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+func TestClientSignedURL(t *testing.T) {
+	client := filepicker.NewClient(FakeApiKey)
+	blob := filepicker.NewBlob(FakeHandle)
+	if _, err := client.SignedURL(blob, time.Minute, nil); err != filepicker.ErrNoSigner {
+		t.Errorf("want err == ErrNoSigner; got %v", err)
+	}
+	client.Signer = filepicker.SecretSigner(FakeSecret)
+	signed, err := client.SignedURL(blob, time.Minute, nil)
+	if err != nil {
+		t.Fatalf("want err == nil; got %v", err)
+	}
+	u, _ := url.Parse(signed)
+	security := filepicker.Security{
+		Policy:    filepicker.Policy(u.Query().Get("policy")),
+		Signature: u.Query().Get("signature"),
+	}
+	if !security.Verify(FakeSecret) {
+		t.Errorf("want URL signed with client's secret; got %q", signed)
+	}
+	if _, err := client.SignedURL(blob, 0, nil); !errors.Is(err, filepicker.ErrInvalidPolicy) {
+		t.Errorf("want ErrInvalidPolicy; got %v", err)
+	}
+}
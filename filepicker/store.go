@@ -2,13 +2,14 @@ package filepicker
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
-	"path"
 	"strings"
 )
 
@@ -42,6 +43,25 @@ type StoreOpts struct {
 	// Access allows to use direct links to underlying file store service.
 	Access string `json:"access,omitempty"`
 
+	// Progress, if set, is called as the data is sent to filepicker service.
+	// It is not used by StoreURL method.
+	Progress ProgressFunc `json:"-"`
+
+	// Verify selects the checksums of the sent data which are compared with
+	// the ones computed by filepicker service. If the verification fails, the
+	// stored blob is returned along with the error, which is VerifyError if
+	// the checksums could not be fetched. Verification needs the permission to
+	// call Stat method. It is not used by StoreURL method and cannot be
+	// combined with Base64Decode, which makes the call fail with
+	// ErrUnverifiable.
+	Verify Checksum `json:"-"`
+
+	// VerifySecurity, if set, is used instead of Security by the Stat call
+	// which fetches the checksums for Verify and thus has to allow stat calls.
+	// If it is not set and the client has a Signer, the Stat call is signed
+	// with its own policy.
+	VerifySecurity Security `json:"-"`
+
 	// Security stores Filepicker.io policy and signature members. If you enable
 	// security option in your developer portal, these values must be set in
 	// order to perform a valid request call.
@@ -61,12 +81,17 @@ func (so *StoreOpts) toValues() url.Values {
 // StoreOpt defines how filepicker.io will store the data. If a nil pointer is
 // provided, this function will use default storage options.
 func (c *Client) Store(name string, opt *StoreOpts) (*Blob, error) {
+	return c.StoreContext(context.Background(), name, opt)
+}
+
+// StoreContext works like Store but binds the request to the provided context.
+func (c *Client) StoreContext(ctx context.Context, name string, opt *StoreOpts) (*Blob, error) {
 	reader, err := os.Open(name)
 	if err != nil {
 		return nil, err
 	}
 	defer reader.Close()
-	return c.StoreReader(name, reader, opt)
+	return c.StoreReaderContext(ctx, name, reader, opt)
 }
 
 // StoreReader allows writing of an arbitary reader to a clients storage bucket
@@ -76,26 +101,146 @@ func (c *Client) Store(name string, opt *StoreOpts) (*Blob, error) {
 // StoreOpt defines how filepicker.io will store the data. If a nil pointer is
 // provided, this function will use default storage options.
 func (c *Client) StoreReader(name string, reader io.Reader, opt *StoreOpts) (*Blob, error) {
-	return c.store(name, reader, func() string {
-		return c.toStoreURL(opt).String()
-	})
+	return c.StoreReaderContext(context.Background(), name, reader, opt)
 }
 
-func (c *Client) store(name string, file io.Reader, fn func() string) (*Blob, error) {
-	buff := &bytes.Buffer{}
-	wr := multipart.NewWriter(buff)
-	mimewr, err := wr.CreateFormFile("fileUpload", name)
+// StoreReaderContext works like StoreReader but binds the request to the
+// provided context.
+func (c *Client) StoreReaderContext(ctx context.Context, name string, reader io.Reader, opt *StoreOpts) (*Blob, error) {
+	storeURL, err := c.toStoreURL(opt)
+	if err != nil {
+		return nil, err
+	}
+	up := &upload{url: storeURL.String()}
+	if opt != nil {
+		up.progress, up.verify, up.decode = opt.Progress, opt.Verify, opt.Base64Decode
+		up.security = c.statSecurity(opt.Security, opt.VerifySecurity)
+	}
+	return c.store(withCall(ctx, &Call{Method: MetStore, Opts: opt}), name, reader, up)
+}
+
+// upload holds the options of a call that sends the content of a file.
+type upload struct {
+	url      string
+	progress ProgressFunc
+	verify   Checksum
+	decode   bool
+	security Security // Used by the Stat call which verifies the upload.
+}
+
+func (c *Client) store(ctx context.Context, name string, file io.Reader, up *upload) (*Blob, error) {
+	if err := verifiable(up.verify, up.decode); err != nil {
+		return nil, err
+	}
+	body, err := newMultipartBody("fileUpload", name, file)
+	if err != nil {
+		return nil, err
+	}
+	body.progress, body.verify = up.progress, up.verify
+	req, err := c.newRequest(ctx, "POST", up.url, body.contentType, body.reader())
 	if err != nil {
 		return nil, err
 	}
-	if _, err = io.Copy(mimewr, file); err != nil {
+	req.ContentLength, req.GetBody = body.length(), body.getBody()
+	blob, err := storeRes(c.send(req))
+	if err != nil {
+		return nil, err
+	}
+	return blob, c.verify(ctx, blob, up.security, body.digester)
+}
+
+// multipartBody streams the content of a reader as a single file field of a
+// multipart form. The data is never buffered in memory, only the form headers
+// and the closing boundary are.
+type multipartBody struct {
+	contentType string
+	head, tail  []byte
+	file        io.Reader
+	size        int64 // Size of the file or -1 if it is unknown.
+	offset      int64 // Start position of the file if it is seekable.
+	progress    ProgressFunc
+	verify      Checksum
+	digester    *digester // Hashes of the most recently sent file content.
+}
+
+// newMultipartBody creates a new multipart form with file's content stored in
+// the field named fieldname.
+func newMultipartBody(fieldname, filename string, file io.Reader) (*multipartBody, error) {
+	buff := &bytes.Buffer{}
+	wr := multipart.NewWriter(buff)
+	if _, err := wr.CreateFormFile(fieldname, filename); err != nil {
 		return nil, err
 	}
-	content := wr.FormDataContentType()
+	head := append([]byte(nil), buff.Bytes()...)
+	buff.Reset()
 	if err := wr.Close(); err != nil {
 		return nil, err
 	}
-	return storeRes(c.do("POST", fn(), content, buff))
+	size, offset := sizeOf(file)
+	return &multipartBody{
+		contentType: wr.FormDataContentType(),
+		head:        head,
+		tail:        buff.Bytes(),
+		file:        file,
+		size:        size,
+		offset:      offset,
+	}, nil
+}
+
+// reader returns a reader that produces the whole multipart form. Reads of the
+// file are reported to the progress callback, if there is one, and hashed when
+// the checksums are to be verified.
+func (mb *multipartBody) reader() io.Reader {
+	mb.digester = newDigester(mb.verify)
+	file := mb.digester.tee(newProgressReader(mb.file, mb.size, mb.progress))
+	return io.MultiReader(bytes.NewReader(mb.head), file, bytes.NewReader(mb.tail))
+}
+
+// length returns the total size of the form or -1 if it cannot be determined.
+func (mb *multipartBody) length() int64 {
+	if mb.size < 0 {
+		return -1
+	}
+	return int64(len(mb.head)) + mb.size + int64(len(mb.tail))
+}
+
+// getBody returns a function that rewinds the file and recreates the form. It
+// returns nil if the file cannot be read again.
+func (mb *multipartBody) getBody() func() (io.ReadCloser, error) {
+	seeker, ok := mb.file.(io.Seeker)
+	if !ok || mb.size < 0 {
+		return nil
+	}
+	return func() (io.ReadCloser, error) {
+		if _, err := seeker.Seek(mb.offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(mb.reader()), nil
+	}
+}
+
+// sizeOf returns the number of bytes left in the reader and its current offset.
+// If the size cannot be determined without consuming the data, it returns -1.
+func sizeOf(r io.Reader) (size, offset int64) {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		if lener, ok := r.(interface{ Len() int }); ok {
+			return int64(lener.Len()), 0
+		}
+		return -1, 0
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1, 0
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return -1, 0
+	}
+	if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+		return -1, 0
+	}
+	return end - offset, offset
 }
 
 // StoreURL takes a URL that points to the data to store and sends them directly
@@ -105,16 +250,24 @@ func (c *Client) store(name string, file io.Reader, fn func() string) (*Blob, er
 // StoreOpt defines how filepicker.io will store the data. If a nil pointer is
 // provided, this function will use default storage options.
 func (c *Client) StoreURL(dataURL string, opt *StoreOpts) (*Blob, error) {
-	return c.storeURL(dataURL, func() string {
-		return c.toStoreURL(opt).String()
-	})
+	return c.StoreURLContext(context.Background(), dataURL, opt)
 }
 
-func (c *Client) storeURL(dataURL string, fn func() string) (*Blob, error) {
+// StoreURLContext works like StoreURL but binds the request to the provided
+// context.
+func (c *Client) StoreURLContext(ctx context.Context, dataURL string, opt *StoreOpts) (*Blob, error) {
+	storeURL, err := c.toStoreURL(opt)
+	if err != nil {
+		return nil, err
+	}
+	return c.storeURL(withCall(ctx, &Call{Method: MetStore, Opts: opt}), dataURL, storeURL)
+}
+
+func (c *Client) storeURL(ctx context.Context, dataURL string, target *url.URL) (*Blob, error) {
 	const content = "application/x-www-form-urlencoded"
 	values := url.Values{}
 	values.Set("url", dataURL)
-	return storeRes(c.do("POST", fn(), content, strings.NewReader(values.Encode())))
+	return storeRes(c.do(ctx, "POST", target.String(), content, strings.NewReader(values.Encode())))
 }
 
 // storeRes handles client response error and, if there is none, this function
@@ -129,12 +282,12 @@ func storeRes(resp *http.Response, respErr error) (*Blob, error) {
 	}
 	blob := &Blob{}
 	if err := json.NewDecoder(resp.Body).Decode(blob); err != nil {
-		return nil, err
+		return nil, failCall(resp, err)
 	}
 	return blob, nil
 }
 
-func (c *Client) toStoreURL(opt *StoreOpts) *url.URL {
+func (c *Client) toStoreURL(opt *StoreOpts) (*url.URL, error) {
 	storage := c.storage
 	values := url.Values{}
 	if opt != nil {
@@ -144,10 +297,10 @@ func (c *Client) toStoreURL(opt *StoreOpts) *url.URL {
 		}
 	}
 	values.Set("key", c.apiKey)
-	return &url.URL{
-		Scheme:   apiURL.Scheme,
-		Host:     apiURL.Host,
-		Path:     path.Join("api", "store", string(storage)),
-		RawQuery: values.Encode(),
+	if err := c.sign(values, storePolicy(opt)); err != nil {
+		return nil, err
 	}
+	storeURL := endpoint(c.BaseURL, "api", "store", string(storage))
+	storeURL.RawQuery = values.Encode()
+	return storeURL, nil
 }
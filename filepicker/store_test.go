@@ -1,11 +1,14 @@
 package filepicker_test
 
 import (
+	"bytes"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/filepicker/filepicker-go/filepicker"
@@ -198,3 +201,87 @@ func TestStoreURLError(t *testing.T) {
 		t.Errorf("want error message == %q; got %q", fperr, err)
 	}
 }
+
+func TestStoreReaderStreaming(t *testing.T) {
+	tests := []struct {
+		Reader        io.Reader
+		ContentLength bool
+	}{
+		{
+			Reader:        strings.NewReader(storeFileContent),
+			ContentLength: true,
+		},
+		{
+			Reader:        bytes.NewBufferString(storeFileContent),
+			ContentLength: true,
+		},
+		{
+			Reader:        struct{ io.Reader }{strings.NewReader(storeFileContent)},
+			ContentLength: false,
+		},
+	}
+
+	var contentLength int64
+	var content string
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		contentLength = req.ContentLength
+		file, header, err := req.FormFile("fileUpload")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := ioutil.ReadAll(file)
+		content = header.Filename + ":" + string(data)
+		w.Write([]byte("{}"))
+	}
+
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	for i, test := range tests {
+		if _, err := client.StoreReader("file.txt", test.Reader, nil); err != nil {
+			t.Errorf("want err == nil; got %v (i:%d)", err, i)
+		}
+		if want := "file.txt:" + storeFileContent; content != want {
+			t.Errorf("want content == %q; got %q (i:%d)", want, content, i)
+		}
+		if known := contentLength > 0; known != test.ContentLength {
+			t.Errorf("want known content length == %t; got %d (i:%d)", test.ContentLength, contentLength, i)
+		}
+	}
+}
+
+func TestStoreProgress(t *testing.T) {
+	var reqURL, reqMethod, reqBody string
+	handler := testHandle(&reqURL, &reqMethod, &reqBody)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	var last filepicker.Progress
+	var calls int
+	opt := &filepicker.StoreOpts{
+		Filename: "file.txt",
+		Progress: func(p filepicker.Progress) {
+			if p.Bytes < last.Bytes {
+				t.Errorf("want p.Bytes >= %d; got %d", last.Bytes, p.Bytes)
+			}
+			last = p
+			calls++
+		},
+	}
+	if _, err := client.StoreReader("file.txt", strings.NewReader(storeFileContent), opt); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if calls == 0 {
+		t.Error("want calls > 0; got 0")
+	}
+	if l := int64(len(storeFileContent)); last.Bytes != l || last.Total != l {
+		t.Errorf("want last.Bytes == last.Total == %d; got %d and %d", l, last.Bytes, last.Total)
+	}
+	if want := "http://www.filepicker.io/api/store/S3?filename=file.txt&key=0KKK1"; reqURL != want {
+		t.Errorf("want reqURL == %q; got %q", want, reqURL)
+	}
+}
@@ -1,6 +1,7 @@
 package filepicker
 
 import (
+	"context"
 	"io"
 	"net/url"
 	"os"
@@ -13,6 +14,25 @@ type WriteOpts struct {
 	// base64 before being written to the file.
 	Base64Decode bool `json:"base64decode,omitempty"`
 
+	// Progress, if set, is called as the data is sent to filepicker service.
+	// It is not used by WriteURL method.
+	Progress ProgressFunc `json:"-"`
+
+	// Verify selects the checksums of the sent data which are compared with
+	// the ones computed by filepicker service. If the verification fails, the
+	// written blob is returned along with the error, which is VerifyError if
+	// the checksums could not be fetched. Verification needs the permission to
+	// call Stat method. It is not used by WriteURL method and cannot be
+	// combined with Base64Decode, which makes the call fail with
+	// ErrUnverifiable.
+	Verify Checksum `json:"-"`
+
+	// VerifySecurity, if set, is used instead of Security by the Stat call
+	// which fetches the checksums for Verify and thus has to allow stat calls.
+	// If it is not set and the client has a Signer, the Stat call is signed
+	// with its own policy.
+	VerifySecurity Security `json:"-"`
+
 	// Security stores Filepicker.io policy and signature members. If you enable
 	// security option in your developer portal, these values must be set in
 	// order to perform a valid request call.
@@ -27,37 +47,66 @@ func (wo *WriteOpts) toValues() url.Values {
 
 // Write TODO : (ppknap)
 func (c *Client) Write(src *Blob, name string, opt *WriteOpts) (*Blob, error) {
+	return c.WriteContext(context.Background(), src, name, opt)
+}
+
+// WriteContext works like Write but binds the request to the provided context.
+func (c *Client) WriteContext(ctx context.Context, src *Blob, name string, opt *WriteOpts) (*Blob, error) {
 	reader, err := os.Open(name)
 	if err != nil {
 		return nil, err
 	}
 	defer reader.Close()
-	return c.WriteReader(src, reader, opt)
+	return c.WriteReaderContext(ctx, src, reader, opt)
 }
 
 // WriteReader TODO : (ppknap)
 func (c *Client) WriteReader(src *Blob, reader io.Reader, opt *WriteOpts) (*Blob, error) {
-	return c.store("", reader, func() string {
-		return c.toWriteURL(src, opt).String()
-	})
+	return c.WriteReaderContext(context.Background(), src, reader, opt)
+}
+
+// WriteReaderContext works like WriteReader but binds the request to the
+// provided context.
+func (c *Client) WriteReaderContext(ctx context.Context, src *Blob, reader io.Reader, opt *WriteOpts) (*Blob, error) {
+	writeURL, err := c.toWriteURL(src, opt, MetWrite)
+	if err != nil {
+		return nil, err
+	}
+	up := &upload{url: writeURL.String()}
+	if opt != nil {
+		up.progress, up.verify, up.decode = opt.Progress, opt.Verify, opt.Base64Decode
+		up.security = c.statSecurity(opt.Security, opt.VerifySecurity)
+	}
+	return c.store(withCall(ctx, &Call{Method: MetWrite, Handle: src.Handle(), Opts: opt}), "", reader, up)
 }
 
 // WriteURL TODO : (ppknap)
 func (c *Client) WriteURL(src *Blob, dataURL string, opt *WriteOpts) (*Blob, error) {
-	return c.storeURL(dataURL, func() string {
-		return c.toWriteURL(src, opt).String()
-	})
+	return c.WriteURLContext(context.Background(), src, dataURL, opt)
+}
+
+// WriteURLContext works like WriteURL but binds the request to the provided
+// context.
+func (c *Client) WriteURLContext(ctx context.Context, src *Blob, dataURL string, opt *WriteOpts) (*Blob, error) {
+	writeURL, err := c.toWriteURL(src, opt, MetWriteurl)
+	if err != nil {
+		return nil, err
+	}
+	return c.storeURL(withCall(ctx, &Call{Method: MetWriteurl, Handle: src.Handle(), Opts: opt}), dataURL, writeURL)
 }
 
-func (c *Client) toWriteURL(src *Blob, opt *WriteOpts) *url.URL {
+func (c *Client) toWriteURL(src *Blob, opt *WriteOpts, method Method) (*url.URL, error) {
 	blobURL, err := url.Parse(src.URL)
 	if err != nil {
-		return &url.URL{}
+		return nil, err
 	}
 	values := url.Values{}
 	if opt != nil {
 		values = opt.toValues()
 	}
+	if err := c.sign(values, blobPolicy(method, src)); err != nil {
+		return nil, err
+	}
 	blobURL.RawQuery = values.Encode()
-	return blobURL
+	return blobURL, nil
 }
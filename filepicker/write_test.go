@@ -1,8 +1,10 @@
 package filepicker_test
 
 import (
+	"io"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/filepicker/filepicker-go/filepicker"
@@ -158,3 +160,30 @@ func TestWriteURLError(t *testing.T) {
 		t.Errorf("want error message == %q; got %q", fperr, err)
 	}
 }
+
+func TestWriteReaderProgress(t *testing.T) {
+	var reqURL, reqMethod, reqBody string
+	handler := testHandle(&reqURL, &reqMethod, &reqBody)
+
+	blob := filepicker.NewBlob(FakeHandle)
+	client := filepicker.NewClient(FakeApiKey)
+	mock := MockServer(t, client, handler)
+	defer mock.Close()
+
+	var last filepicker.Progress
+	opt := &filepicker.WriteOpts{
+		Progress: func(p filepicker.Progress) {
+			last = p
+		},
+	}
+	reader := struct{ io.Reader }{strings.NewReader(storeFileContent)}
+	if _, err := client.WriteReader(blob, reader, opt); err != nil {
+		t.Errorf("want err == nil; got %v", err)
+	}
+	if l := int64(len(storeFileContent)); last.Bytes != l {
+		t.Errorf("want last.Bytes == %d; got %d", l, last.Bytes)
+	}
+	if last.Total != -1 {
+		t.Errorf("want last.Total == -1; got %d", last.Total)
+	}
+}